@@ -0,0 +1,109 @@
+package adapter
+
+import (
+	"fmt"
+	"time"
+)
+
+// Heartbeat timing
+const (
+	HeartbeatInterval = 2 * time.Second        // time between pings
+	PingTimeout       = 500 * time.Millisecond // how long a ping waits for its reply
+	DegradedLatency   = 50 * time.Millisecond  // round trips slower than this are degraded
+	MissesUntilDown   = 3                      // missed pings in a row before a connection is down
+)
+
+// Health is the state of a connection as measured by its heartbeat
+type Health int
+
+const (
+	HealthUnknown  Health = iota // no ping has completed yet
+	HealthUp                     // answering quickly
+	HealthDegraded               // answering slowly, or a ping was missed
+	HealthDown                   // not answering
+)
+
+// String returns the health for display
+func (h Health) String() string {
+	switch h {
+	case HealthUp:
+		return "up"
+	case HealthDegraded:
+		return "degraded"
+	case HealthDown:
+		return "down"
+	}
+	return "unknown"
+}
+
+// Heartbeat tracks a connection's health from the outcome of periodic pings
+// UDP sends never fail when nothing is listening, so only replies tell
+type Heartbeat struct {
+	latency  time.Duration // round trip of the last answered ping
+	misses   int           // pings missed in a row
+	answered bool          // a ping has ever been answered
+	pinged   bool          // a ping has completed
+}
+
+// Record adds the outcome of a ping
+func (h *Heartbeat) Record(latency time.Duration, err error) {
+	h.pinged = true
+	if err != nil {
+		h.misses++
+		return
+	}
+	h.misses = 0
+	h.answered = true
+	h.latency = latency
+}
+
+// Health returns the connection state
+// A connection that has never answered is down after its first miss
+func (h Heartbeat) Health() Health {
+	switch {
+	case !h.pinged:
+		return HealthUnknown
+	case h.misses >= MissesUntilDown || (h.misses > 0 && !h.answered):
+		return HealthDown
+	case h.misses > 0 || h.latency > DegradedLatency:
+		return HealthDegraded
+	}
+	return HealthUp
+}
+
+// Latency returns the round trip of the last answered ping
+func (h Heartbeat) Latency() time.Duration {
+	return h.latency
+}
+
+// Describe returns the health with its latency or missed pings, e.g. "up 1.2ms"
+func (h Heartbeat) Describe() string {
+	switch health := h.Health(); {
+	case health == HealthUp || (health == HealthDegraded && h.misses == 0):
+		return fmt.Sprintf("%s %.1fms", health, float64(h.latency.Microseconds())/1000)
+	case health == HealthDegraded:
+		return fmt.Sprintf("%s (%d missed)", health, h.misses)
+	default:
+		return health.String()
+	}
+}
+
+// PingSClang measures the round trip to sclang
+// setup.scd answers /forbidden/ping with /forbidden/pong
+func PingSClang(sclangAdapter *OSCAdapter, timeout time.Duration) (time.Duration, error) {
+	start := time.Now()
+	if _, err := sclangAdapter.Request("/forbidden/pong", timeout, "/forbidden/ping"); err != nil {
+		return 0, fmt.Errorf("sclang ping failed: %w", err)
+	}
+	return time.Since(start), nil
+}
+
+// Ping measures the round trip of a /status request, returning the status too
+func (s *ScsynthAdapter) Ping(timeout time.Duration) (time.Duration, ServerStatus, error) {
+	start := time.Now()
+	status, err := s.Status(timeout)
+	if err != nil {
+		return 0, ServerStatus{}, fmt.Errorf("scsynth ping failed: %w", err)
+	}
+	return time.Since(start), status, nil
+}
@@ -0,0 +1,59 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+)
+
+// ping is the outcome of one heartbeat: a round trip, or a miss
+type ping struct {
+	latency time.Duration
+	missed  bool
+}
+
+var (
+	fast   = ping{latency: 2 * time.Millisecond}
+	slow   = ping{latency: 80 * time.Millisecond}
+	missed = ping{missed: true}
+)
+
+func TestHeartbeatHealth(t *testing.T) {
+	tests := []struct {
+		name     string
+		pings    []ping
+		want     Health
+		describe string
+	}{
+		{"no pings", nil, HealthUnknown, "unknown"},
+		{"fast reply", []ping{fast}, HealthUp, "up 2.0ms"},
+		{"slow reply", []ping{slow}, HealthDegraded, "degraded 80.0ms"},
+		{"never answered", []ping{missed}, HealthDown, "down"},
+		{"one miss after replies", []ping{fast, missed}, HealthDegraded, "degraded (1 missed)"},
+		{"misses short of down", []ping{fast, missed, missed}, HealthDegraded, "degraded (2 missed)"},
+		{"misses until down", []ping{fast, missed, missed, missed}, HealthDown, "down"},
+		{"reply after misses recovers", []ping{fast, missed, missed, missed, fast}, HealthUp, "up 2.0ms"},
+		{"misses reset between replies", []ping{fast, missed, missed, fast, missed, missed}, HealthDegraded, "degraded (2 missed)"},
+		{"slow after fast", []ping{fast, slow}, HealthDegraded, "degraded 80.0ms"},
+		{"first reply after down", []ping{missed, missed, slow}, HealthDegraded, "degraded 80.0ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h Heartbeat
+			for _, p := range tt.pings {
+				var err error
+				if p.missed {
+					err = ErrTimeout
+				}
+				h.Record(p.latency, err)
+			}
+
+			if got := h.Health(); got != tt.want {
+				t.Errorf("Health = %v, want %v", got, tt.want)
+			}
+			if got := h.Describe(); got != tt.describe {
+				t.Errorf("Describe = %q, want %q", got, tt.describe)
+			}
+		})
+	}
+}
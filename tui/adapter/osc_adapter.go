@@ -1,52 +1,454 @@
 package adapter
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/hypebeast/go-osc/osc"
 )
 
-// OSCAdapter provides generic OSC communication
+// subscriberBufferSize is the number of incoming messages buffered per subscriber
+// Messages are dropped for a subscriber whose buffer is full
+const subscriberBufferSize = 64
+
+// errorBufferSize is the number of send errors buffered for Errors
+// Errors are dropped while the buffer is full
+const errorBufferSize = 16
+
+// trafficBufferSize is the number of sent and received messages buffered for Traffic
+// Entries are dropped while the buffer is full
+const trafficBufferSize = 256
+
+// Backoff between failed reads, doubling while reads keep failing, so a
+// persistent socket error doesn't spin the receive goroutine
+const (
+	minReadBackoff = 10 * time.Millisecond
+	maxReadBackoff = time.Second
+)
+
+// Direction says whether a logged message was sent or received
+type Direction int
+
+const (
+	Outgoing Direction = iota
+	Incoming
+)
+
+// Traffic is a message that went through an adapter, for logging
+type Traffic struct {
+	Time      time.Time
+	Direction Direction
+	Message   *osc.Message
+}
+
+// HandlerFunc handles an incoming OSC message
+// Handlers run on the adapter's receive goroutine
+type HandlerFunc func(msg *osc.Message)
+
+// handler pairs an address pattern with its handler
+type handler struct {
+	pattern string
+	fn      HandlerFunc
+}
+
+// subscriber pairs an address pattern with its delivery channel
+type subscriber struct {
+	pattern string
+	ch      chan *osc.Message
+}
+
+// OSCAdapter provides generic bidirectional OSC communication
 // Used for pattern control and TUI communication with SuperCollider
+// Messages are sent from a bound local UDP port, so replies from sclang
+// (which answers to the sender's address) arrive back on the same socket
 type OSCAdapter struct {
-	client *osc.Client
+	conn   *net.UDPConn
+	target *net.UDPAddr
 	host   string
 	port   int
+
+	mu          sync.Mutex
+	handlers    []handler
+	subscribers []subscriber
+	waiters     []subscriber // one-shot replies awaited by Request
+	listening   bool
+
+	errors  chan error   // send failures, read with Errors
+	traffic chan Traffic // sent and received messages, read with Traffic
+	retry   retryQueue   // failed SendLatest and SendLatestKeyed values
 }
 
-// NewOSCAdapter creates a new OSC adapter
+// NewOSCAdapter creates a new OSC adapter bound to an ephemeral local UDP port
 // host: target host (e.g., "localhost" or "127.0.0.1")
 // port: target port (e.g., 57120 for SuperCollider sclang)
 func NewOSCAdapter(host string, port int) (*OSCAdapter, error) {
-	client := osc.NewClient(host, port)
+	return NewOSCAdapterWithLocalPort(host, port, 0)
+}
+
+// NewOSCAdapterWithLocalPort creates a new OSC adapter bound to the given local UDP port
+// localPort: port to receive replies on (0 picks an ephemeral port)
+func NewOSCAdapterWithLocalPort(host string, port int, localPort int) (*OSCAdapter, error) {
+	target, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", host, port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve OSC target %s:%d: %w", host, port, err)
+	}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: localPort})
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind local OSC port %d: %w", localPort, err)
+	}
 
 	return &OSCAdapter{
-		client: client,
-		host:   host,
-		port:   port,
+		conn:    conn,
+		target:  target,
+		host:    host,
+		port:    port,
+		errors:  make(chan error, errorBufferSize),
+		traffic: make(chan Traffic, trafficBufferSize),
 	}, nil
 }
 
 // GetHost returns the current OSC host
 func (o *OSCAdapter) GetHost() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	return o.host
 }
 
 // GetPort returns the current OSC port
 func (o *OSCAdapter) GetPort() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	return o.port
 }
 
+// GetLocalPort returns the local UDP port replies are received on
+func (o *OSCAdapter) GetLocalPort() int {
+	return o.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
 // SetTarget changes the OSC target host and port
-func (o *OSCAdapter) SetTarget(host string, port int) {
+func (o *OSCAdapter) SetTarget(host string, port int) error {
+	target, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", host, port))
+	if err != nil {
+		return fmt.Errorf("failed to resolve OSC target %s:%d: %w", host, port, err)
+	}
+
+	o.mu.Lock()
 	o.host = host
 	o.port = port
-	o.client = osc.NewClient(host, port)
+	o.target = target
+	o.mu.Unlock()
+
+	return nil
 }
 
 // Send sends an OSC message with the given address and arguments
+// A failure is also reported on Errors as a *SendError
 func (o *OSCAdapter) Send(address string, args ...interface{}) error {
+	if err := o.write(address, args); err != nil {
+		sendErr := &SendError{Address: address, Err: err}
+		select {
+		case o.errors <- sendErr:
+		default:
+			// Nobody is keeping up with errors, drop it
+		}
+		return sendErr
+	}
+	return nil
+}
+
+// SendLatest sends a value of which only the latest matters, such as a parameter
+// If the send fails it is queued and re-sent by RetryPending, replacing any
+// value already queued for the address
+func (o *OSCAdapter) SendLatest(address string, args ...interface{}) error {
+	return o.SendLatestKeyed(address, address, args...)
+}
+
+// SendLatestKeyed is SendLatest for an address carrying several independent
+// values: only a failed send with the same key is replaced, e.g. one key per
+// matrix row sent to the same address
+func (o *OSCAdapter) SendLatestKeyed(key, address string, args ...interface{}) error {
+	err := o.Send(address, args...)
+	if err != nil {
+		o.retry.put(key, address, args)
+	} else {
+		o.retry.remove(key)
+	}
+	return err
+}
+
+// RetryPending re-sends queued values, oldest first, stopping at the first failure
+func (o *OSCAdapter) RetryPending() error {
+	for {
+		key, pending, ok := o.retry.oldest()
+		if !ok {
+			return nil
+		}
+		if err := o.Send(pending.address, pending.args...); err != nil {
+			return err
+		}
+		o.retry.remove(key)
+	}
+}
+
+// Pending returns the number of sends waiting to be re-sent
+func (o *OSCAdapter) Pending() int {
+	return o.retry.len()
+}
+
+// Errors returns a channel receiving every failed send
+func (o *OSCAdapter) Errors() <-chan error {
+	return o.errors
+}
+
+// Traffic returns a channel receiving every message sent or received
+func (o *OSCAdapter) Traffic() <-chan Traffic {
+	return o.traffic
+}
+
+// logTraffic publishes a message on Traffic
+func (o *OSCAdapter) logTraffic(direction Direction, msg *osc.Message) {
+	select {
+	case o.traffic <- Traffic{Time: time.Now(), Direction: direction, Message: msg}:
+	default:
+		// Nobody is keeping up with the log, drop it
+	}
+}
+
+// write encodes and transmits a message to the current target
+func (o *OSCAdapter) write(address string, args []interface{}) error {
 	msg := osc.NewMessage(address)
 	for _, arg := range args {
 		msg.Append(arg)
 	}
-	return o.client.Send(msg)
+
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to encode OSC message %s: %w", address, err)
+	}
+
+	o.mu.Lock()
+	target := o.target
+	o.mu.Unlock()
+
+	if _, err := o.conn.WriteToUDP(data, target); err != nil {
+		return err
+	}
+	o.logTraffic(Outgoing, msg)
+	return nil
+}
+
+// Handle registers a handler for incoming messages matching an address pattern
+// Patterns use OSC wildcards per path segment (e.g. "/pattern/*/state")
+// An empty pattern matches every address
+func (o *OSCAdapter) Handle(pattern string, fn HandlerFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.handlers = append(o.handlers, handler{pattern: pattern, fn: fn})
+}
+
+// Subscribe returns a channel receiving incoming messages matching an address pattern
+// The channel is closed when the adapter is closed
+func (o *OSCAdapter) Subscribe(pattern string) <-chan *osc.Message {
+	ch := make(chan *osc.Message, subscriberBufferSize)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.subscribers = append(o.subscribers, subscriber{pattern: pattern, ch: ch})
+
+	return ch
+}
+
+// Request sends a message and waits for the first reply matching replyPattern
+// Returns an error if nothing matching arrives within the timeout
+func (o *OSCAdapter) Request(replyPattern string, timeout time.Duration, address string, args ...interface{}) (*osc.Message, error) {
+	o.Listen()
+
+	reply := make(chan *osc.Message, 1)
+	o.mu.Lock()
+	o.waiters = append(o.waiters, subscriber{pattern: replyPattern, ch: reply})
+	o.mu.Unlock()
+
+	defer o.removeWaiter(reply)
+
+	if err := o.Send(address, args...); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-reply:
+		return msg, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("no reply to %s within %v", address, timeout)
+	}
+}
+
+// removeWaiter stops waiting for a Request reply
+func (o *OSCAdapter) removeWaiter(ch chan *osc.Message) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, w := range o.waiters {
+		if w.ch == ch {
+			o.waiters = append(o.waiters[:i:i], o.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// Listen starts receiving OSC messages on the local port in the background
+// Calling Listen more than once has no effect
+func (o *OSCAdapter) Listen() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.listening {
+		return
+	}
+	o.listening = true
+
+	go o.receiveLoop()
+}
+
+// Close stops listening, releases the local port and closes all subscriptions
+// While listening, the receive goroutine closes them as it exits
+func (o *OSCAdapter) Close() error {
+	err := o.conn.Close()
+
+	o.mu.Lock()
+	listening := o.listening
+	o.mu.Unlock()
+	if !listening {
+		o.closeSubscribers()
+	}
+	return err
+}
+
+// receiveLoop reads packets until the connection is closed
+func (o *OSCAdapter) receiveLoop() {
+	defer o.closeSubscribers()
+
+	buf := make([]byte, 65535)
+	backoff := minReadBackoff
+	for {
+		n, _, err := o.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			// Transient errors (e.g. ICMP port unreachable) - keep listening
+			time.Sleep(backoff)
+			backoff = min(2*backoff, maxReadBackoff)
+			continue
+		}
+		backoff = minReadBackoff
+
+		packet, err := parsePacket(buf[:n])
+		if err != nil || packet == nil {
+			continue
+		}
+		o.dispatchPacket(packet)
+	}
+}
+
+// dispatchPacket delivers a message, or every message in a bundle, to matching handlers
+func (o *OSCAdapter) dispatchPacket(packet osc.Packet) {
+	switch p := packet.(type) {
+	case *osc.Message:
+		o.dispatch(p)
+	case *osc.Bundle:
+		for _, msg := range p.Messages {
+			o.dispatch(msg)
+		}
+		for _, bundle := range p.Bundles {
+			o.dispatchPacket(bundle)
+		}
+	}
+}
+
+// dispatch delivers a single message to matching handlers and subscribers
+func (o *OSCAdapter) dispatch(msg *osc.Message) {
+	o.logTraffic(Incoming, msg)
+
+	o.mu.Lock()
+	handlers := append([]handler(nil), o.handlers...)
+	subscribers := append([]subscriber(nil), o.subscribers...)
+	var waiters []subscriber
+	for i := 0; i < len(o.waiters); i++ {
+		if MatchAddress(o.waiters[i].pattern, msg.Address) {
+			waiters = append(waiters, o.waiters[i])
+			o.waiters = append(o.waiters[:i:i], o.waiters[i+1:]...)
+			i--
+		}
+	}
+	o.mu.Unlock()
+
+	for _, w := range waiters {
+		w.ch <- msg
+	}
+
+	for _, h := range handlers {
+		if MatchAddress(h.pattern, msg.Address) {
+			h.fn(msg)
+		}
+	}
+
+	for _, s := range subscribers {
+		if MatchAddress(s.pattern, msg.Address) {
+			select {
+			case s.ch <- msg:
+			default:
+				// Subscriber is not keeping up, drop the message
+			}
+		}
+	}
+}
+
+// closeSubscribers closes every subscription channel
+func (o *OSCAdapter) closeSubscribers() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, s := range o.subscribers {
+		close(s.ch)
+	}
+	o.subscribers = nil
+	o.listening = false
+}
+
+// MatchAddress reports whether an OSC address matches a pattern
+// Each path segment is matched with OSC-style wildcards (*, ?, [...])
+// An empty pattern matches every address
+func MatchAddress(pattern, address string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	patternParts := strings.Split(pattern, "/")
+	addressParts := strings.Split(address, "/")
+	if len(patternParts) != len(addressParts) {
+		return false
+	}
+
+	for i, part := range patternParts {
+		matched, err := path.Match(part, addressParts[i])
+		if err != nil || !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parsePacket decodes a raw OSC packet
+func parsePacket(data []byte) (osc.Packet, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return osc.ParsePacket(string(data))
 }
@@ -0,0 +1,68 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+)
+
+// closedWithin reports whether ch is closed (after draining anything buffered)
+func closedWithin[T any](ch <-chan T, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return true
+			}
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func TestCloseClosesSubscriptions(t *testing.T) {
+	for _, listen := range []bool{false, true} {
+		o, err := NewOSCAdapter("127.0.0.1", DefaultSClangPort)
+		if err != nil {
+			t.Fatalf("failed to create adapter: %v", err)
+		}
+		ch := o.Subscribe("/pattern/*/state")
+		if listen {
+			o.Listen()
+		}
+
+		if err := o.Close(); err != nil {
+			t.Errorf("Close (listening %v) failed: %v", listen, err)
+		}
+		if !closedWithin(ch, time.Second) {
+			t.Errorf("subscription not closed by Close (listening %v)", listen)
+		}
+	}
+}
+
+func TestMatchAddress(t *testing.T) {
+	tests := []struct {
+		pattern, address string
+		want             bool
+	}{
+		{"", "/anything/at/all", true},
+		{"/pattern/euclid/state", "/pattern/euclid/state", true},
+		{"/pattern/euclid/state", "/pattern/euclid/transport", false},
+		{"/pattern/*/state", "/pattern/euclid/state", true},
+		{"/pattern/*/state", "/pattern/euclid/bd/state", false},
+		{"/pattern/*", "/pattern", false},
+		{"/pattern/markov_?rig/state", "/pattern/markov_trig/state", true},
+		{"/pattern/[ce]*/state", "/pattern/euclid/state", true},
+		{"/pattern/[ce]*/state", "/pattern/markov_chord/state", false},
+		{"/n_set", "/n_set", true},
+		{"/status.reply", "/status.reply", true},
+		{"/status.*", "/status.reply", true},
+		{"/pattern/[/state", "/pattern/[/state", false}, // malformed pattern
+	}
+
+	for _, tt := range tests {
+		if got := MatchAddress(tt.pattern, tt.address); got != tt.want {
+			t.Errorf("MatchAddress(%q, %q) = %v, want %v", tt.pattern, tt.address, got, tt.want)
+		}
+	}
+}
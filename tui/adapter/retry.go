@@ -0,0 +1,90 @@
+package adapter
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// retryQueueSize is the most values kept for retry; the oldest is dropped beyond it
+const retryQueueSize = 64
+
+// SendError is a failed OSC send
+type SendError struct {
+	Address string
+	Err     error
+}
+
+// Error describes the failed send
+func (e *SendError) Error() string {
+	return fmt.Sprintf("failed to send %s: %v", e.Address, e.Err)
+}
+
+// Unwrap returns the socket error
+func (e *SendError) Unwrap() error {
+	return e.Err
+}
+
+// pendingSend is a queued message
+type pendingSend struct {
+	address string
+	args    []interface{}
+}
+
+// retryQueue keeps the latest failed send per key, oldest first
+// The key is usually the address; it is finer where one address carries
+// several independent values (e.g. a matrix row or a node control)
+// Only the latest value matters, so a newer failure replaces the queued one
+// and a later successful send removes it
+type retryQueue struct {
+	mu     sync.Mutex
+	order  []string
+	latest map[string]pendingSend
+}
+
+// put queues a send under key, replacing anything queued for it
+func (q *retryQueue) put(key, address string, args []interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.latest == nil {
+		q.latest = make(map[string]pendingSend)
+	}
+	if _, ok := q.latest[key]; ok {
+		q.order = slices.DeleteFunc(q.order, func(k string) bool { return k == key })
+	} else if len(q.order) >= retryQueueSize {
+		delete(q.latest, q.order[0])
+		q.order = q.order[1:]
+	}
+	q.order = append(q.order, key)
+	q.latest[key] = pendingSend{address: address, args: slices.Clone(args)}
+}
+
+// remove drops anything queued under key
+func (q *retryQueue) remove(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.latest[key]; ok {
+		delete(q.latest, key)
+		q.order = slices.DeleteFunc(q.order, func(k string) bool { return k == key })
+	}
+}
+
+// oldest returns the key and message of the first queued send
+func (q *retryQueue) oldest() (string, pendingSend, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.order) == 0 {
+		return "", pendingSend{}, false
+	}
+	return q.order[0], q.latest[q.order[0]], true
+}
+
+// len returns the number of queued sends
+func (q *retryQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.order)
+}
@@ -0,0 +1,92 @@
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRetryQueue(t *testing.T) {
+	type op struct {
+		put bool // put, otherwise remove
+		key string
+	}
+	tests := []struct {
+		name string
+		ops  []op
+		want []string // keys, oldest first
+	}{
+		{"empty", nil, nil},
+		{"in order", []op{{true, "/a"}, {true, "/b"}}, []string{"/a", "/b"}},
+		{"newer value moves to the back", []op{{true, "/a"}, {true, "/b"}, {true, "/a"}}, []string{"/b", "/a"}},
+		{"remove", []op{{true, "/a"}, {true, "/b"}, {false, "/a"}}, []string{"/b"}},
+		{"remove unknown", []op{{true, "/a"}, {false, "/c"}}, []string{"/a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q retryQueue
+			for _, o := range tt.ops {
+				if o.put {
+					q.put(o.key, o.key, nil)
+				} else {
+					q.remove(o.key)
+				}
+			}
+
+			var got []string
+			for q.len() > 0 {
+				key, _, _ := q.oldest()
+				got = append(got, key)
+				q.remove(key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("queued %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryQueueKeepsLatestArgs(t *testing.T) {
+	var q retryQueue
+	args := []interface{}{"kick", float32(0.5)}
+	q.put("/n_set 1000 size", "/n_set", args)
+	args[1] = float32(0.9) // the queue keeps its own copy
+	q.put("/n_set 1000 wet", "/n_set", []interface{}{"wet", float32(0.2)})
+	q.put("/n_set 1000 size", "/n_set", []interface{}{"kick", float32(0.7)})
+
+	key, pending, ok := q.oldest()
+	if !ok || key != "/n_set 1000 wet" {
+		t.Fatalf("oldest = %q, want the wet control", key)
+	}
+	q.remove(key)
+	_, pending, _ = q.oldest()
+	if pending.address != "/n_set" || !reflect.DeepEqual(pending.args, []interface{}{"kick", float32(0.7)}) {
+		t.Errorf("queued %s %v, want the latest size value", pending.address, pending.args)
+	}
+}
+
+func TestRetryQueueDropsOldestWhenFull(t *testing.T) {
+	var q retryQueue
+	for i := range retryQueueSize + 3 {
+		q.put(fmt.Sprintf("/p/%d", i), fmt.Sprintf("/p/%d", i), nil)
+	}
+	if q.len() != retryQueueSize {
+		t.Errorf("len = %d, want %d", q.len(), retryQueueSize)
+	}
+	if key, _, _ := q.oldest(); key != "/p/3" {
+		t.Errorf("oldest = %s, want /p/3", key)
+	}
+}
+
+func TestSendErrorUnwraps(t *testing.T) {
+	cause := errors.New("network is unreachable")
+	err := error(&SendError{Address: "/pattern/euclid/play", Err: cause})
+	if !errors.Is(err, cause) {
+		t.Error("SendError doesn't unwrap to its cause")
+	}
+	if want := "failed to send /pattern/euclid/play: network is unreachable"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
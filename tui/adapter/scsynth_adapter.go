@@ -0,0 +1,296 @@
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// Fixed server resources created by setup.scd
+const (
+	SynthsGroupID  = 100  // group pattern synths are added to
+	EffectsGroupID = 200  // group after the synths holding the effects
+	ReverbBus      = 10   // stereo audio bus read by the reverb
+	ReverbNodeID   = 1000 // fdnReverb synth in the effects group
+)
+
+// firstNodeID is the first node ID handed out by AllocNodeID
+// sclang's client 0 allocates below 1 << 26, so the next block is free
+// as long as no second client logs into the server
+const firstNodeID = 1 << 26
+
+// AddAction says where /s_new and /g_new place a node relative to the target
+type AddAction int32
+
+const (
+	AddToHead  AddAction = 0 // first in the target group
+	AddToTail  AddAction = 1 // last in the target group
+	AddBefore  AddAction = 2 // just before the target node
+	AddAfter   AddAction = 3 // just after the target node
+	AddReplace AddAction = 4 // in place of the target node, which is freed
+)
+
+// ErrTimeout is returned when scsynth doesn't answer in time (e.g. it isn't running)
+var ErrTimeout = errors.New("timed out waiting for scsynth")
+
+// ServerStatus is the server load reported in /status.reply
+type ServerStatus struct {
+	UGens             int
+	Synths            int
+	Groups            int
+	SynthDefs         int
+	AvgCPU            float64 // percent
+	PeakCPU           float64 // percent
+	NominalSampleRate float64
+	ActualSampleRate  float64
+}
+
+// ScsynthAdapter speaks the scsynth node, group and bus commands over UDP (port 57110)
+// Commands are fire-and-forget like every OSC send; Sync and Status wait
+// for the server's reply. Pointing it at any UDP address (e.g. a fake
+// server on localhost) makes it usable without SuperCollider running
+type ScsynthAdapter struct {
+	*OSCAdapter
+
+	mu            sync.Mutex
+	nextNodeID    int32
+	nextSyncID    int32
+	syncWaiters   map[int32]chan struct{}
+	statusWaiters []chan ServerStatus
+}
+
+// NewScsynthAdapter creates an adapter for the server at host:port
+func NewScsynthAdapter(host string, port int) (*ScsynthAdapter, error) {
+	oscAdapter, err := NewOSCAdapter(host, port)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ScsynthAdapter{
+		OSCAdapter:  oscAdapter,
+		nextNodeID:  firstNodeID,
+		syncWaiters: make(map[int32]chan struct{}),
+	}
+	s.Handle("/synced", s.handleSynced)
+	s.Handle("/status.reply", s.handleStatus)
+
+	return s, nil
+}
+
+// AllocNodeID returns a node ID no other caller of this adapter has been given
+func (s *ScsynthAdapter) AllocNodeID() int32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextNodeID
+	s.nextNodeID++
+	return id
+}
+
+// SNew creates a synth from a SynthDef
+// controls alternate names and values, e.g. "freq", float32(440)
+func (s *ScsynthAdapter) SNew(defName string, nodeID int32, action AddAction, targetID int32, controls ...interface{}) error {
+	args := append([]interface{}{defName, nodeID, int32(action), targetID}, controls...)
+	return s.Send("/s_new", args...)
+}
+
+// NSet sets controls of a node; controls alternate names and values
+func (s *ScsynthAdapter) NSet(nodeID int32, controls ...interface{}) error {
+	args := append([]interface{}{nodeID}, controls...)
+	return s.Send("/n_set", args...)
+}
+
+// NSetLatest sets one control of a node, retrying it like SendLatest if the
+// send fails; queued values are kept per node and control
+func (s *ScsynthAdapter) NSetLatest(nodeID int32, control string, value interface{}) error {
+	key := fmt.Sprintf("/n_set %d %s", nodeID, control)
+	return s.SendLatestKeyed(key, "/n_set", nodeID, control, value)
+}
+
+// SGet asks for the current value of a node's controls
+// scsynth answers with an /n_set of the node ID and name/value pairs
+func (s *ScsynthAdapter) SGet(nodeID int32, controls ...string) error {
+	args := []interface{}{nodeID}
+	for _, control := range controls {
+		args = append(args, control)
+	}
+	return s.Send("/s_get", args...)
+}
+
+// NFree frees one or more nodes
+func (s *ScsynthAdapter) NFree(nodeIDs ...int32) error {
+	return s.Send("/n_free", int32Args(nodeIDs)...)
+}
+
+// GNew creates a group
+func (s *ScsynthAdapter) GNew(groupID int32, action AddAction, targetID int32) error {
+	return s.Send("/g_new", groupID, int32(action), targetID)
+}
+
+// GFreeAll frees every node inside one or more groups, keeping the groups
+func (s *ScsynthAdapter) GFreeAll(groupIDs ...int32) error {
+	return s.Send("/g_freeAll", int32Args(groupIDs)...)
+}
+
+// CSet sets control buses; pairs alternate bus index and value, e.g. int32(10), float32(0.5)
+func (s *ScsynthAdapter) CSet(pairs ...interface{}) error {
+	return s.Send("/c_set", pairs...)
+}
+
+// CGet asks for the current value of control buses
+// scsynth answers with a /c_set of bus index and value pairs
+func (s *ScsynthAdapter) CGet(buses ...int32) error {
+	return s.Send("/c_get", int32Args(buses)...)
+}
+
+// Sync waits until scsynth has completed every command sent before it
+// (e.g. a SynthDef load before the /s_new that uses it)
+func (s *ScsynthAdapter) Sync(timeout time.Duration) error {
+	s.Listen()
+
+	s.mu.Lock()
+	s.nextSyncID++
+	id := s.nextSyncID
+	done := make(chan struct{})
+	s.syncWaiters[id] = done
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.syncWaiters, id)
+		s.mu.Unlock()
+	}()
+
+	if err := s.Send("/sync", id); err != nil {
+		return fmt.Errorf("failed to send /sync: %w", err)
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("no /synced after %v: %w", timeout, ErrTimeout)
+	}
+}
+
+// Status asks scsynth for its load and waits for the /status.reply
+func (s *ScsynthAdapter) Status(timeout time.Duration) (ServerStatus, error) {
+	s.Listen()
+
+	reply := make(chan ServerStatus, 1)
+	s.mu.Lock()
+	s.statusWaiters = append(s.statusWaiters, reply)
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		for i, w := range s.statusWaiters {
+			if w == reply {
+				s.statusWaiters = append(s.statusWaiters[:i:i], s.statusWaiters[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+	}()
+
+	if err := s.Send("/status"); err != nil {
+		return ServerStatus{}, fmt.Errorf("failed to send /status: %w", err)
+	}
+
+	select {
+	case status := <-reply:
+		return status, nil
+	case <-time.After(timeout):
+		return ServerStatus{}, fmt.Errorf("no /status.reply after %v: %w", timeout, ErrTimeout)
+	}
+}
+
+// handleSynced wakes the Sync call waiting for the reply's ID
+func (s *ScsynthAdapter) handleSynced(msg *osc.Message) {
+	if len(msg.Arguments) < 1 {
+		return
+	}
+	id, ok := msg.Arguments[0].(int32)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if done, ok := s.syncWaiters[id]; ok {
+		close(done)
+		delete(s.syncWaiters, id)
+	}
+}
+
+// handleStatus hands a /status.reply to every waiting Status call
+func (s *ScsynthAdapter) handleStatus(msg *osc.Message) {
+	status, err := ParseStatusReply(msg)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	waiters := s.statusWaiters
+	s.statusWaiters = nil
+	s.mu.Unlock()
+
+	for _, reply := range waiters {
+		reply <- status
+	}
+}
+
+// ParseStatusReply decodes a /status.reply message
+// Arguments: 1, UGens, synths, groups, SynthDefs, avg CPU, peak CPU,
+// nominal sample rate, actual sample rate
+func ParseStatusReply(msg *osc.Message) (ServerStatus, error) {
+	if msg.Address != "/status.reply" || len(msg.Arguments) < 9 {
+		return ServerStatus{}, fmt.Errorf("malformed /status.reply: %v", msg.Arguments)
+	}
+
+	var values [8]float64
+	for i := range values {
+		v, ok := numberArg(msg.Arguments[i+1])
+		if !ok {
+			return ServerStatus{}, fmt.Errorf("malformed /status.reply argument %d: %v", i+1, msg.Arguments[i+1])
+		}
+		values[i] = v
+	}
+
+	return ServerStatus{
+		UGens:             int(values[0]),
+		Synths:            int(values[1]),
+		Groups:            int(values[2]),
+		SynthDefs:         int(values[3]),
+		AvgCPU:            values[4],
+		PeakCPU:           values[5],
+		NominalSampleRate: values[6],
+		ActualSampleRate:  values[7],
+	}, nil
+}
+
+// numberArg converts a numeric OSC argument to float64
+func numberArg(arg interface{}) (float64, bool) {
+	switch v := arg.(type) {
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// int32Args converts IDs to OSC arguments
+func int32Args(ids []int32) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
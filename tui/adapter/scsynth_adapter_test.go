@@ -0,0 +1,336 @@
+package adapter
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// fakeServer is a UDP socket standing in for scsynth
+// Every message received is passed on received; reply answers the last sender
+type fakeServer struct {
+	conn     *net.UDPConn
+	received chan *osc.Message
+	sender   chan *net.UDPAddr
+}
+
+// newFakeServer listens on a localhost port until the test ends
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to start fake server: %v", err)
+	}
+	f := &fakeServer{
+		conn:     conn,
+		received: make(chan *osc.Message, 16),
+		sender:   make(chan *net.UDPAddr, 16),
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if packet, err := parsePacket(buf[:n]); err == nil {
+				if msg, ok := packet.(*osc.Message); ok {
+					f.sender <- from
+					f.received <- msg
+				}
+			}
+		}
+	}()
+	return f
+}
+
+// adapter returns a ScsynthAdapter pointed at the fake server
+func (f *fakeServer) adapter(t *testing.T) *ScsynthAdapter {
+	t.Helper()
+	s, err := NewScsynthAdapter("127.0.0.1", f.conn.LocalAddr().(*net.UDPAddr).Port)
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// next returns the next message the server received
+func (f *fakeServer) next(t *testing.T) (*osc.Message, *net.UDPAddr) {
+	t.Helper()
+	select {
+	case from := <-f.sender:
+		return <-f.received, from
+	case <-time.After(time.Second):
+		t.Fatal("fake server received nothing")
+		return nil, nil
+	}
+}
+
+// reply sends a message back to addr
+func (f *fakeServer) reply(t *testing.T, addr *net.UDPAddr, address string, args ...interface{}) {
+	t.Helper()
+	msg := osc.NewMessage(address, args...)
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to encode %s: %v", address, err)
+	}
+	if _, err := f.conn.WriteToUDP(data, addr); err != nil {
+		t.Fatalf("failed to reply %s: %v", address, err)
+	}
+}
+
+func TestScsynthCommandEncoding(t *testing.T) {
+	server := newFakeServer(t)
+	s := server.adapter(t)
+
+	tests := []struct {
+		name    string
+		send    func() error
+		address string
+		args    []interface{}
+	}{
+		{
+			"s_new",
+			func() error {
+				return s.SNew("kick", 67108864, AddToTail, SynthsGroupID, "freq", float32(55), "out", int32(0))
+			},
+			"/s_new",
+			[]interface{}{"kick", int32(67108864), int32(1), int32(100), "freq", float32(55), "out", int32(0)},
+		},
+		{
+			"n_set",
+			func() error { return s.NSet(ReverbNodeID, "wet", float32(0.5)) },
+			"/n_set",
+			[]interface{}{int32(1000), "wet", float32(0.5)},
+		},
+		{
+			"n_set latest",
+			func() error { return s.NSetLatest(ReverbNodeID, "size", float32(0.7)) },
+			"/n_set",
+			[]interface{}{int32(1000), "size", float32(0.7)},
+		},
+		{
+			"s_get",
+			func() error { return s.SGet(ReverbNodeID, "size", "wet") },
+			"/s_get",
+			[]interface{}{int32(1000), "size", "wet"},
+		},
+		{
+			"c_set",
+			func() error { return s.CSet(int32(3), float32(0.25), int32(4), float32(880)) },
+			"/c_set",
+			[]interface{}{int32(3), float32(0.25), int32(4), float32(880)},
+		},
+		{
+			"c_get",
+			func() error { return s.CGet(3, 4) },
+			"/c_get",
+			[]interface{}{int32(3), int32(4)},
+		},
+		{
+			"n_free",
+			func() error { return s.NFree(2001, 2002) },
+			"/n_free",
+			[]interface{}{int32(2001), int32(2002)},
+		},
+		{
+			"g_new",
+			func() error { return s.GNew(EffectsGroupID, AddAfter, SynthsGroupID) },
+			"/g_new",
+			[]interface{}{int32(200), int32(3), int32(100)},
+		},
+		{
+			"g_freeAll",
+			func() error { return s.GFreeAll(SynthsGroupID) },
+			"/g_freeAll",
+			[]interface{}{int32(100)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.send(); err != nil {
+				t.Fatalf("send failed: %v", err)
+			}
+			msg, _ := server.next(t)
+			if msg.Address != tt.address {
+				t.Errorf("address = %s, want %s", msg.Address, tt.address)
+			}
+			if !reflect.DeepEqual(msg.Arguments, tt.args) {
+				t.Errorf("arguments = %#v, want %#v", msg.Arguments, tt.args)
+			}
+		})
+	}
+}
+
+func TestNSetLatestRetriesPerControl(t *testing.T) {
+	server := newFakeServer(t)
+	s := server.adapter(t)
+	port := s.GetPort()
+
+	// Port 0 can't be sent to, so every send fails and is queued
+	if err := s.SetTarget("127.0.0.1", 0); err != nil {
+		t.Fatalf("SetTarget failed: %v", err)
+	}
+	s.NSetLatest(ReverbNodeID, "size", float32(0.5))
+	s.NSetLatest(ReverbNodeID, "wet", float32(0.2))
+	s.NSetLatest(ReverbNodeID, "size", float32(0.6))
+	if got := s.Pending(); got != 2 {
+		t.Fatalf("Pending = %d, want 2 (one per control)", got)
+	}
+
+	s.SetTarget("127.0.0.1", port)
+	if err := s.RetryPending(); err != nil {
+		t.Fatalf("RetryPending failed: %v", err)
+	}
+	want := [][]interface{}{
+		{int32(1000), "wet", float32(0.2)},
+		{int32(1000), "size", float32(0.6)},
+	}
+	for _, args := range want {
+		msg, _ := server.next(t)
+		if msg.Address != "/n_set" || !reflect.DeepEqual(msg.Arguments, args) {
+			t.Errorf("retried %s %v, want /n_set %v", msg.Address, msg.Arguments, args)
+		}
+	}
+}
+
+func TestAllocNodeID(t *testing.T) {
+	s := newFakeServer(t).adapter(t)
+	first, second := s.AllocNodeID(), s.AllocNodeID()
+	if first != firstNodeID || second != firstNodeID+1 {
+		t.Errorf("AllocNodeID gave %d, %d, want %d, %d", first, second, firstNodeID, firstNodeID+1)
+	}
+}
+
+func TestSyncCompletesOnMatchingSynced(t *testing.T) {
+	server := newFakeServer(t)
+	s := server.adapter(t)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Sync(time.Second) }()
+
+	msg, from := server.next(t)
+	if msg.Address != "/sync" || len(msg.Arguments) != 1 {
+		t.Fatalf("got %s %v, want /sync with an ID", msg.Address, msg.Arguments)
+	}
+	id := msg.Arguments[0].(int32)
+
+	// A reply for another sync must not complete this one
+	server.reply(t, from, "/synced", id+1)
+	select {
+	case err := <-done:
+		t.Fatalf("Sync returned on a mismatched ID: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	server.reply(t, from, "/synced", id)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Sync failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Sync didn't complete on /synced")
+	}
+}
+
+func TestSyncTimeout(t *testing.T) {
+	server := newFakeServer(t)
+	s := server.adapter(t)
+
+	start := time.Now()
+	err := s.Sync(50 * time.Millisecond)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Sync without a reply returned %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Sync gave up after %v, before its timeout", elapsed)
+	}
+	server.next(t) // the /sync was still sent
+}
+
+func TestStatus(t *testing.T) {
+	server := newFakeServer(t)
+	s := server.adapter(t)
+
+	type result struct {
+		status ServerStatus
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		status, err := s.Status(time.Second)
+		done <- result{status, err}
+	}()
+
+	msg, from := server.next(t)
+	if msg.Address != "/status" {
+		t.Fatalf("got %s, want /status", msg.Address)
+	}
+	server.reply(t, from, "/status.reply", int32(1), int32(120), int32(8), int32(3), int32(42),
+		float32(4.5), float32(9), float64(48000), float64(47999.5))
+
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("Status failed: %v", r.err)
+	}
+	if r.status.Synths != 8 || r.status.AvgCPU != 4.5 {
+		t.Errorf("Status = %+v", r.status)
+	}
+}
+
+func TestParseStatusReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     *osc.Message
+		want    ServerStatus
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			msg: osc.NewMessage("/status.reply", int32(1), int32(120), int32(8), int32(3), int32(42),
+				float32(4.5), float32(9.25), float64(48000), float64(47999.5)),
+			want: ServerStatus{
+				UGens: 120, Synths: 8, Groups: 3, SynthDefs: 42,
+				AvgCPU: 4.5, PeakCPU: 9.25, NominalSampleRate: 48000, ActualSampleRate: 47999.5,
+			},
+		},
+		{
+			name:    "short",
+			msg:     osc.NewMessage("/status.reply", int32(1), int32(120), int32(8)),
+			wantErr: true,
+		},
+		{
+			name: "non-numeric",
+			msg: osc.NewMessage("/status.reply", int32(1), "lots", int32(8), int32(3), int32(42),
+				float32(4.5), float32(9.25), float64(48000), float64(47999.5)),
+			wantErr: true,
+		},
+		{
+			name: "wrong address",
+			msg: osc.NewMessage("/done", int32(1), int32(120), int32(8), int32(3), int32(42),
+				float32(4.5), float32(9.25), float64(48000), float64(47999.5)),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStatusReply(tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
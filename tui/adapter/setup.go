@@ -2,14 +2,32 @@ package adapter
 
 import "fmt"
 
+// Default SuperCollider target
+const (
+	DefaultHost        = "localhost"
+	DefaultSClangPort  = 57120 // sclang, for pattern control
+	DefaultScsynthPort = 57110 // scsynth, for direct server control
+)
+
 // SetupSClangAdapter creates and configures an OSC adapter for sclang pattern control
-// Sends control messages to SuperCollider lang (port 57120) for pattern control
-func SetupSClangAdapter() (*OSCAdapter, error) {
-	// Initialize OSC adapter for sclang (localhost:57120 is sclang default port)
-	sclangAdapter, err := NewOSCAdapter("localhost", 57120)
+// Sends control messages to SuperCollider lang (usually port 57120) for pattern control
+func SetupSClangAdapter(host string, port int) (*OSCAdapter, error) {
+	sclangAdapter, err := NewOSCAdapter(host, port)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize sclang OSC adapter: %w", err)
 	}
 
 	return sclangAdapter, nil
 }
+
+// SetupScsynthAdapter creates and configures an adapter for direct server control
+// Sends node commands to the SuperCollider server (usually port 57110), e.g. for the reverb
+// scsynth replies to the sender's address, so answers arrive on this adapter
+func SetupScsynthAdapter(host string, port int) (*ScsynthAdapter, error) {
+	scsynthAdapter, err := NewScsynthAdapter(host, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize scsynth OSC adapter: %w", err)
+	}
+
+	return scsynthAdapter, nil
+}
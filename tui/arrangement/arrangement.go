@@ -0,0 +1,128 @@
+package arrangement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"forbidden_sequencer/automation"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Arrangement is an ordered list of sections that make up a set
+// Arrangements are written by hand as JSON or YAML, e.g.
+//
+//	sections:
+//	  - {name: Intro, length: 8, parts: [{pattern: markov_trig, preset: A}]}
+//	  - {name: Build, length: 16, parts: [{pattern: curve_time, preset: B}]}
+//	  - {name: Both, length: 60, unit: seconds, parts: [{pattern: markov_trig}, {pattern: curve_time}]}
+type Arrangement struct {
+	Name     string    `json:"name" yaml:"name"`
+	Loop     bool      `json:"loop" yaml:"loop"` // start again after the last section
+	Sections []Section `json:"sections" yaml:"sections"`
+}
+
+// Section plays a set of patterns for a length of time
+type Section struct {
+	Name   string  `json:"name" yaml:"name"`
+	Parts  []Part  `json:"parts" yaml:"parts"`
+	Length float64 `json:"length" yaml:"length"`
+	Unit   string  `json:"unit" yaml:"unit"` // "phrases" (default) or "seconds"
+}
+
+// Part is one pattern playing in a section, optionally from a preset
+type Part struct {
+	Pattern string `json:"pattern" yaml:"pattern"` // controller ID, the last segment of its namespace
+	Preset  string `json:"preset" yaml:"preset"`   // preset name ("" keeps the current values)
+}
+
+// LengthUnit returns the unit the section length is expressed in
+func (s Section) LengthUnit() automation.LengthUnit {
+	if s.Unit == "seconds" {
+		return automation.Seconds
+	}
+	return automation.Phrases
+}
+
+// Describe returns the section's length and patterns for display
+func (s Section) Describe() string {
+	parts := make([]string, len(s.Parts))
+	for i, p := range s.Parts {
+		parts[i] = p.Pattern
+		if p.Preset != "" {
+			parts[i] += " (" + p.Preset + ")"
+		}
+	}
+	return fmt.Sprintf("%g %s: %s", s.Length, s.LengthUnit(), strings.Join(parts, " + "))
+}
+
+// Patterns returns the ID of every pattern used in the arrangement, in order of first use
+func (a *Arrangement) Patterns() []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, s := range a.Sections {
+		for _, p := range s.Parts {
+			if !seen[p.Pattern] {
+				seen[p.Pattern] = true
+				ids = append(ids, p.Pattern)
+			}
+		}
+	}
+	return ids
+}
+
+// Validate checks the arrangement is playable
+func (a *Arrangement) Validate() error {
+	if len(a.Sections) == 0 {
+		return errors.New("arrangement has no sections")
+	}
+	for i, s := range a.Sections {
+		if s.Name == "" {
+			a.Sections[i].Name = fmt.Sprintf("Section %d", i+1)
+		}
+		if s.Length <= 0 {
+			return fmt.Errorf("section %d has no length", i+1)
+		}
+		if s.Unit != "" && s.Unit != "phrases" && s.Unit != "seconds" {
+			return fmt.Errorf("section %d: unit %q must be phrases or seconds", i+1, s.Unit)
+		}
+		for j, p := range s.Parts {
+			if p.Pattern == "" {
+				return fmt.Errorf("section %d part %d has no pattern", i+1, j+1)
+			}
+		}
+	}
+	return nil
+}
+
+// Load reads and validates a JSON or YAML arrangement
+// Returns nil without an error if the file doesn't exist
+func Load(path string) (*Arrangement, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read arrangement: %w", err)
+	}
+
+	var arrangement Arrangement
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &arrangement)
+	} else {
+		err = yaml.Unmarshal(data, &arrangement)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse arrangement %s: %w", filepath.Base(path), err)
+	}
+
+	if err := arrangement.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid arrangement %s: %w", filepath.Base(path), err)
+	}
+
+	return &arrangement, nil
+}
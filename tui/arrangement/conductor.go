@@ -0,0 +1,253 @@
+package arrangement
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"forbidden_sequencer/automation"
+	"forbidden_sequencer/controllers"
+)
+
+// PresetLookup returns the values of a controller's named preset
+type PresetLookup func(controllerID, name string) (map[string]float64, error)
+
+// Conductor walks an arrangement, starting, stopping and restoring patterns
+// as each section begins. Patterns are driven through their controllers, so
+// every message still goes out through the controllers' OSC adapter
+type Conductor struct {
+	arrangement   *Arrangement
+	controllers   map[string]controllers.Controller // by ID
+	presets       PresetLookup
+	defaultPhrase func() float64 // phrase length in seconds for patterns that don't report one
+
+	index    int           // current section (-1 before the start)
+	total    time.Duration // length of the current section
+	elapsed  time.Duration // time spent in the current section
+	lastTick time.Time
+	started  map[string]bool // patterns started by the conductor
+	running  bool
+	err      error // last error entering a section
+}
+
+// NewConductor creates a conductor for an arrangement
+// Every part must name a controller that can be played as a layer
+func NewConductor(arrangement *Arrangement, available []controllers.Controller, presets PresetLookup, defaultPhrase func() float64) (*Conductor, error) {
+	type identified interface{ ID() string }
+
+	byID := make(map[string]controllers.Controller)
+	for _, c := range available {
+		if id, ok := c.(identified); ok {
+			if _, ok := c.(controllers.Layerable); ok {
+				byID[id.ID()] = c
+			}
+		}
+	}
+
+	for _, id := range arrangement.Patterns() {
+		if _, ok := byID[id]; !ok {
+			return nil, fmt.Errorf("arrangement uses unknown pattern %q", id)
+		}
+	}
+
+	return &Conductor{
+		arrangement:   arrangement,
+		controllers:   byID,
+		presets:       presets,
+		defaultPhrase: defaultPhrase,
+		index:         -1,
+		started:       make(map[string]bool),
+	}, nil
+}
+
+// Arrangement returns the arrangement being conducted
+func (c *Conductor) Arrangement() *Arrangement {
+	return c.arrangement
+}
+
+// Start plays the arrangement from the first section
+func (c *Conductor) Start(now time.Time) {
+	c.running = true
+	c.enter(0, now)
+}
+
+// Stop stops every pattern the conductor started
+func (c *Conductor) Stop() {
+	for id := range c.started {
+		c.layer(id).Stop()
+	}
+	c.started = make(map[string]bool)
+	c.running = false
+	c.index = -1
+}
+
+// Skip moves straight to the next section
+func (c *Conductor) Skip(now time.Time) {
+	if c.running {
+		c.advance(now)
+	}
+}
+
+// Tick advances to now, moving to the next section when the current one ends
+// Time run past the end of a section counts towards the next one, so the
+// arrangement doesn't fall behind by up to a tick at every change
+// Returns true if the section changed
+func (c *Conductor) Tick(now time.Time) bool {
+	if !c.running {
+		return false
+	}
+
+	if !c.lastTick.IsZero() {
+		c.elapsed += now.Sub(c.lastTick)
+	}
+	c.lastTick = now
+
+	if c.elapsed < c.total {
+		return false
+	}
+	overshoot := c.elapsed - c.total
+	c.advance(now)
+	if c.running {
+		c.elapsed = overshoot
+	}
+	return true
+}
+
+// Running reports whether the arrangement is playing
+func (c *Conductor) Running() bool {
+	return c.running
+}
+
+// Index returns the current section index (-1 when stopped)
+func (c *Conductor) Index() int {
+	return c.index
+}
+
+// Current returns the current section, or nil when stopped
+func (c *Conductor) Current() *Section {
+	if c.index < 0 {
+		return nil
+	}
+	return &c.arrangement.Sections[c.index]
+}
+
+// Next returns the section after the current one, or nil at the end
+func (c *Conductor) Next() *Section {
+	next := c.index + 1
+	if next >= len(c.arrangement.Sections) {
+		if !c.arrangement.Loop {
+			return nil
+		}
+		next = 0
+	}
+	return &c.arrangement.Sections[next]
+}
+
+// Progress returns how far through the current section we are (0-1)
+func (c *Conductor) Progress() float64 {
+	if c.total <= 0 {
+		return 1
+	}
+	return math.Min(1, float64(c.elapsed)/float64(c.total))
+}
+
+// Remaining returns the time left in the current section
+func (c *Conductor) Remaining() time.Duration {
+	if c.elapsed >= c.total {
+		return 0
+	}
+	return c.total - c.elapsed
+}
+
+// Err returns the last error from entering a section (e.g. a missing preset)
+func (c *Conductor) Err() error {
+	return c.err
+}
+
+// advance enters the next section, looping or finishing at the end
+func (c *Conductor) advance(now time.Time) {
+	next := c.index + 1
+	if next >= len(c.arrangement.Sections) {
+		if !c.arrangement.Loop {
+			c.Stop()
+			return
+		}
+		next = 0
+	}
+	c.enter(next, now)
+}
+
+// enter starts a section from its beginning: restores presets, plays its
+// patterns and stops the rest (Tick carries any overshoot in afterwards)
+// Patterns carried over from the previous section keep playing
+func (c *Conductor) enter(index int, now time.Time) {
+	section := c.arrangement.Sections[index]
+	c.index = index
+	c.elapsed = 0
+	c.lastTick = now
+	c.err = nil
+
+	wanted := make(map[string]bool, len(section.Parts))
+	for _, part := range section.Parts {
+		wanted[part.Pattern] = true
+		controller := c.controllers[part.Pattern]
+
+		if part.Preset != "" {
+			if err := c.restore(controller, part); err != nil {
+				c.err = err
+			}
+		}
+
+		if layer := c.layer(part.Pattern); !layer.IsPlaying() {
+			layer.Play()
+		}
+		c.started[part.Pattern] = true
+	}
+
+	for id := range c.started {
+		if !wanted[id] {
+			c.layer(id).Stop()
+			delete(c.started, id)
+		}
+	}
+
+	c.total = c.length(section)
+}
+
+// restore applies a part's preset to its controller
+func (c *Conductor) restore(controller controllers.Controller, part Part) error {
+	presettable, ok := controller.(controllers.Presettable)
+	if !ok || c.presets == nil {
+		return fmt.Errorf("pattern %q has no presets", part.Pattern)
+	}
+	values, err := c.presets(presettable.ID(), part.Preset)
+	if err != nil {
+		return fmt.Errorf("failed to load preset %q for %s: %w", part.Preset, part.Pattern, err)
+	}
+	presettable.Restore(values)
+	return nil
+}
+
+// length returns a section's duration, measuring phrases with the longest
+// phrase among its patterns
+func (c *Conductor) length(section Section) time.Duration {
+	seconds := section.Length
+	if section.LengthUnit() == automation.Phrases {
+		phrase := 0.0
+		for _, part := range section.Parts {
+			if target, ok := c.controllers[part.Pattern].(automation.Target); ok {
+				phrase = math.Max(phrase, target.PhraseDuration())
+			}
+		}
+		if phrase <= 0 && c.defaultPhrase != nil {
+			phrase = c.defaultPhrase()
+		}
+		seconds *= phrase
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// layer returns the transport of a pattern by ID
+func (c *Conductor) layer(id string) controllers.Layerable {
+	return c.controllers[id].(controllers.Layerable)
+}
@@ -0,0 +1,189 @@
+package arrangement
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"forbidden_sequencer/controllers"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakePattern is a layerable, presettable automation target with a fixed phrase
+type fakePattern struct {
+	id       string
+	phrase   float64
+	playing  bool
+	plays    int
+	restored map[string]float64
+}
+
+func (f *fakePattern) GetName() string                       { return f.id }
+func (f *fakePattern) GetKeybindings() string                { return "" }
+func (f *fakePattern) GetStatus() string                     { return "" }
+func (f *fakePattern) HandleInput(msg tea.KeyMsg) bool       { return false }
+func (f *fakePattern) Quit()                                 { f.playing = false }
+func (f *fakePattern) ID() string                            { return f.id }
+func (f *fakePattern) IsPlaying() bool                       { return f.playing }
+func (f *fakePattern) Play()                                 { f.playing = true; f.plays++ }
+func (f *fakePattern) Stop()                                 { f.playing = false }
+func (f *fakePattern) Muted() bool                           { return false }
+func (f *fakePattern) SetMuted(muted bool)                   {}
+func (f *fakePattern) Snapshot() map[string]float64          { return nil }
+func (f *fakePattern) Restore(snapshot map[string]float64)   { f.restored = snapshot }
+func (f *fakePattern) Param(name string) *controllers.Param  { return nil }
+func (f *fakePattern) Value(name string) float64             { return 0 }
+func (f *fakePattern) Limits(name string) (float64, float64) { return 0, 1 }
+func (f *fakePattern) SetValue(name string, v float64) bool  { return false }
+func (f *fakePattern) PhraseDuration() float64               { return f.phrase }
+
+// newTestConductor conducts sections over two fake patterns: "a" with a 2s
+// phrase and "b" with none, so b-only sections fall back to a 4s bar
+func newTestConductor(t *testing.T, loop bool, sections ...Section) (*Conductor, *fakePattern, *fakePattern) {
+	t.Helper()
+	a, b := &fakePattern{id: "a", phrase: 2}, &fakePattern{id: "b"}
+	presets := func(id, name string) (map[string]float64, error) {
+		if name == "missing" {
+			return nil, errors.New("no such preset")
+		}
+		return map[string]float64{"amp": 0.5}, nil
+	}
+	arr := &Arrangement{Loop: loop, Sections: sections}
+	if err := arr.Validate(); err != nil {
+		t.Fatalf("invalid test arrangement: %v", err)
+	}
+	c, err := NewConductor(arr, []controllers.Controller{a, b}, presets, func() float64 { return 4 })
+	if err != nil {
+		t.Fatalf("NewConductor failed: %v", err)
+	}
+	return c, a, b
+}
+
+func at(start time.Time, seconds float64) time.Time {
+	return start.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+func TestConductorAdvancesAndStops(t *testing.T) {
+	c, a, b := newTestConductor(t, false,
+		Section{Length: 2, Parts: []Part{{Pattern: "a"}}},                  // 2 phrases of 2s
+		Section{Length: 1, Parts: []Part{{Pattern: "b"}}},                  // 1 bar of 4s
+		Section{Length: 3, Unit: "seconds", Parts: []Part{{Pattern: "a"}}}, // 3s
+	)
+	start := time.Now()
+	c.Start(start)
+	c.Tick(start)
+
+	steps := []struct {
+		at       float64
+		changed  bool
+		index    int
+		aPlaying bool
+		bPlaying bool
+	}{
+		{3.9, false, 0, true, false},
+		{4.0, true, 1, false, true},
+		{7.9, false, 1, false, true},
+		{8.0, true, 2, true, false},
+		{11.0, true, -1, false, false}, // ends without looping
+	}
+	for _, s := range steps {
+		if changed := c.Tick(at(start, s.at)); changed != s.changed {
+			t.Errorf("at %gs: changed = %v, want %v", s.at, changed, s.changed)
+		}
+		if c.Index() != s.index || a.playing != s.aPlaying || b.playing != s.bPlaying {
+			t.Errorf("at %gs: section %d, a %v, b %v; want %d, %v, %v",
+				s.at, c.Index(), a.playing, b.playing, s.index, s.aPlaying, s.bPlaying)
+		}
+	}
+	if c.Running() {
+		t.Error("conductor still running after the last section")
+	}
+}
+
+func TestConductorLoops(t *testing.T) {
+	c, _, _ := newTestConductor(t, true,
+		Section{Length: 1, Unit: "seconds", Parts: []Part{{Pattern: "a"}}},
+		Section{Length: 1, Unit: "seconds", Parts: []Part{{Pattern: "b"}}},
+	)
+	start := time.Now()
+	c.Start(start)
+	c.Tick(start)
+	c.Tick(at(start, 1))
+	c.Tick(at(start, 2))
+	if !c.Running() || c.Index() != 0 {
+		t.Errorf("after the last section: running %v at %d, want looped to 0", c.Running(), c.Index())
+	}
+}
+
+func TestConductorKeepsCarriedOverPatterns(t *testing.T) {
+	c, a, _ := newTestConductor(t, false,
+		Section{Length: 1, Unit: "seconds", Parts: []Part{{Pattern: "a"}}},
+		Section{Length: 1, Unit: "seconds", Parts: []Part{{Pattern: "a"}, {Pattern: "b", Preset: "missing"}}},
+	)
+	start := time.Now()
+	c.Start(start)
+	c.Tick(start)
+	c.Tick(at(start, 1))
+
+	if a.plays != 1 || !a.playing {
+		t.Errorf("carried-over pattern restarted: %d plays, playing %v", a.plays, a.playing)
+	}
+	if c.Err() == nil {
+		t.Error("missing preset not reported")
+	}
+}
+
+func TestConductorCarriesOvershoot(t *testing.T) {
+	c, _, _ := newTestConductor(t, false,
+		Section{Length: 1, Unit: "seconds", Parts: []Part{{Pattern: "a"}}},
+		Section{Length: 1, Unit: "seconds", Parts: []Part{{Pattern: "a"}}},
+		Section{Length: 1, Unit: "seconds", Parts: []Part{{Pattern: "a"}}},
+	)
+	start := time.Now()
+	c.Start(start)
+	c.Tick(start)
+
+	// Ticks 50ms apart overshoot the first section by 30ms
+	c.Tick(at(start, 0.98))
+	c.Tick(at(start, 1.03))
+	if got := c.Remaining(); got != 970*time.Millisecond {
+		t.Errorf("Remaining after a 30ms overshoot = %v, want 970ms", got)
+	}
+
+	// The third section still starts on the 2s mark
+	if !c.Tick(at(start, 2.0)) || c.Index() != 2 {
+		t.Errorf("section %d at 2s, want 2", c.Index())
+	}
+
+}
+
+func TestConductorSkipStartsFromZero(t *testing.T) {
+	c, _, _ := newTestConductor(t, false,
+		Section{Length: 1, Unit: "seconds", Parts: []Part{{Pattern: "a"}}},
+		Section{Length: 1, Unit: "seconds", Parts: []Part{{Pattern: "a"}}},
+	)
+	start := time.Now()
+	c.Start(start)
+	c.Tick(start)
+	c.Tick(at(start, 0.5))
+
+	c.Skip(at(start, 0.5))
+	if c.Index() != 1 || c.Remaining() != time.Second {
+		t.Errorf("after skip: section %d with %v left, want 1 with 1s", c.Index(), c.Remaining())
+	}
+	c.Skip(at(start, 0.6))
+	if c.Running() {
+		t.Error("skipping past the last section didn't stop")
+	}
+}
+
+func TestConductorRestoresPresets(t *testing.T) {
+	c, a, _ := newTestConductor(t, false,
+		Section{Length: 1, Parts: []Part{{Pattern: "a", Preset: "Intro"}}},
+	)
+	c.Start(time.Now())
+	if a.restored["amp"] != 0.5 || c.Err() != nil {
+		t.Errorf("preset not restored: %v, err %v", a.restored, c.Err())
+	}
+}
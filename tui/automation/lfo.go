@@ -0,0 +1,185 @@
+package automation
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Shape is a modulator waveform
+type Shape int
+
+const (
+	Sine Shape = iota
+	Triangle
+	Square
+	SampleHold
+	RandomWalk
+	Envelope // one-shot swell over a single cycle, then back to centre
+	numShapes
+)
+
+// String returns the shape name for display
+func (s Shape) String() string {
+	switch s {
+	case Triangle:
+		return "triangle"
+	case Square:
+		return "square"
+	case SampleHold:
+		return "s&h"
+	case RandomWalk:
+		return "walk"
+	case Envelope:
+		return "envelope"
+	}
+	return "sine"
+}
+
+// Next returns the following shape, wrapping around
+func (s Shape) Next() Shape {
+	return (s + 1) % numShapes
+}
+
+// Rate and depth limits
+const (
+	MinRate = 0.01 // Hz
+	MaxRate = 20.0 // Hz
+
+	// walkSpeed is the largest random walk step per cycle (in -1..1 units)
+	walkSpeed = 2.0
+)
+
+// Modulator moves one parameter of a target around a centre value
+// Depth is a fraction of the parameter's range: 1.0 sweeps the full range
+type Modulator struct {
+	target Target
+	param  string
+	center float64 // value the parameter had when the modulator was assigned
+
+	Shape Shape
+	Rate  float64 // cycles per second
+	Depth float64 // 0-1, fraction of the parameter range
+	Phase float64 // 0-1, offset into the cycle
+
+	start   time.Time
+	last    time.Time  // previous tick (for the random walk step)
+	cycle   int64      // last cycle index (for sample & hold)
+	held    float64    // current sample & hold level (-1..1)
+	walk    float64    // current random walk level (-1..1)
+	rng     *rand.Rand // seeded per modulator so runs are repeatable
+	current float64    // last value sent
+}
+
+// NewModulator assigns a modulator to a parameter, centred on its current value
+func NewModulator(target Target, param string, seed int64) *Modulator {
+	return &Modulator{
+		target:  target,
+		param:   param,
+		center:  target.Value(param),
+		Shape:   Sine,
+		Rate:    0.5,
+		Depth:   0.25,
+		rng:     rand.New(rand.NewSource(seed)),
+		current: target.Value(param),
+	}
+}
+
+// Target returns the controller being modulated
+func (m *Modulator) Target() Target {
+	return m.target
+}
+
+// Param returns the name of the modulated parameter
+func (m *Modulator) Param() string {
+	return m.param
+}
+
+// Value returns the last value sent
+func (m *Modulator) Value() float64 {
+	return m.current
+}
+
+// Center returns the value the parameter is modulated around
+func (m *Modulator) Center() float64 {
+	return m.center
+}
+
+// Tick computes the modulated value for now and sends it if it changed
+func (m *Modulator) Tick(now time.Time) {
+	if m.start.IsZero() {
+		m.start = now
+	}
+	if m.last.IsZero() {
+		m.last = now
+	}
+	dt := now.Sub(m.last).Seconds()
+	m.last = now
+
+	// Clamp here too, so deep modulation rests at the bounds rather than
+	// relying on every target to clamp
+	min, max := m.target.Limits(m.param)
+	level := m.level(now.Sub(m.start).Seconds(), dt)
+	v := m.center + level*m.Depth*(max-min)/2
+	v = math.Max(min, math.Min(max, v))
+
+	m.target.SetValue(m.param, v)
+	m.current = m.target.Value(m.param)
+}
+
+// level returns the waveform output (-1..1) at t seconds, dt seconds after
+// the previous tick
+func (m *Modulator) level(t, dt float64) float64 {
+	pos := t*m.Rate + m.Phase
+	frac := pos - math.Floor(pos)
+
+	switch m.Shape {
+	case Triangle:
+		return 1 - 4*math.Abs(frac-0.5)
+	case Square:
+		if frac < 0.5 {
+			return 1
+		}
+		return -1
+	case SampleHold:
+		// New random level once per cycle
+		if cycle := int64(math.Floor(pos)); cycle != m.cycle {
+			m.cycle = cycle
+			m.held = m.rng.Float64()*2 - 1
+		}
+		return m.held
+	case RandomWalk:
+		// Random steps scaled by rate and elapsed time, so the walk moves
+		// at the same speed whatever the tick interval, bounded to -1..1
+		m.walk += (m.rng.Float64()*2 - 1) * m.Rate * walkSpeed * dt
+		m.walk = math.Max(-1, math.Min(1, m.walk))
+		return m.walk
+	case Envelope:
+		// Rise and fall once (from Phase), then rest at the centre
+		if pos >= 1 {
+			return 0
+		}
+		return math.Sin(math.Pi * frac)
+	}
+	return math.Sin(2 * math.Pi * frac)
+}
+
+// Retrigger restarts the cycle (useful for envelopes)
+func (m *Modulator) Retrigger() {
+	m.start = time.Time{}
+}
+
+// Release puts the parameter back to its centre value
+func (m *Modulator) Release() {
+	m.target.SetValue(m.param, m.center)
+}
+
+// Describe summarises the modulator settings (e.g. "sine 0.50Hz ±25%")
+func (m *Modulator) Describe() string {
+	desc := fmt.Sprintf("%s %.2fHz ±%.0f%%", m.Shape, m.Rate, m.Depth*100)
+	if m.Phase != 0 {
+		desc += fmt.Sprintf(" φ%.2f", m.Phase)
+	}
+	return desc
+}
@@ -0,0 +1,159 @@
+package automation
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// epoch is a fixed time so ticks are deterministic
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// modulate ticks a new modulator at the epoch and offset after it
+func modulate(shape Shape, phase, center float64, offset time.Duration) (*Modulator, *fakeTarget) {
+	target := &fakeTarget{values: map[string]float64{"amp": center}}
+	mod := NewModulator(target, "amp", 1)
+	mod.Shape = shape
+	mod.Rate = 1
+	mod.Depth = 0.5
+	mod.Phase = phase
+
+	mod.Tick(epoch)
+	mod.Tick(epoch.Add(offset))
+	return mod, target
+}
+
+func TestModulatorShapes(t *testing.T) {
+	// Depth 0.5 of a 0-1 range swings ±0.25 around the centre
+	tests := []struct {
+		name   string
+		shape  Shape
+		phase  float64
+		offset time.Duration
+		want   float64
+	}{
+		{"sine start", Sine, 0, 0, 0.5},
+		{"sine peak", Sine, 0, 250 * time.Millisecond, 0.75},
+		{"sine trough", Sine, 0, 750 * time.Millisecond, 0.25},
+		{"sine phase offset", Sine, 0.25, 0, 0.75},
+		{"triangle start", Triangle, 0, 0, 0.25},
+		{"triangle peak", Triangle, 0, 500 * time.Millisecond, 0.75},
+		{"triangle next cycle", Triangle, 0, 1500 * time.Millisecond, 0.75},
+		{"square high", Square, 0, 250 * time.Millisecond, 0.75},
+		{"square low", Square, 0, 750 * time.Millisecond, 0.25},
+		{"envelope peak", Envelope, 0, 500 * time.Millisecond, 0.75},
+		{"envelope rests after a cycle", Envelope, 0, 1500 * time.Millisecond, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod, target := modulate(tt.shape, tt.phase, 0.5, tt.offset)
+			if got := target.values["amp"]; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("amp = %g, want %g", got, tt.want)
+			}
+			if got := mod.Value(); got != target.values["amp"] {
+				t.Errorf("Value = %g, want the value sent (%g)", got, target.values["amp"])
+			}
+		})
+	}
+}
+
+func TestModulatorSampleHold(t *testing.T) {
+	mod, target := modulate(SampleHold, 0, 0.5, 100*time.Millisecond)
+	held := target.values["amp"]
+	if held < 0.25 || held > 0.75 {
+		t.Fatalf("amp = %g, want within ±0.25 of the centre", held)
+	}
+
+	// Holds for the rest of the cycle
+	mod.Tick(epoch.Add(900 * time.Millisecond))
+	if got := target.values["amp"]; got != held {
+		t.Errorf("amp later in the cycle = %g, want held at %g", got, held)
+	}
+
+	// Same seed and times give the same level
+	_, again := modulate(SampleHold, 0, 0.5, 100*time.Millisecond)
+	if got := again.values["amp"]; got != held {
+		t.Errorf("amp with the same seed = %g, want %g", got, held)
+	}
+
+	// A new sample each cycle
+	mod.Tick(epoch.Add(1100 * time.Millisecond))
+	if got := target.values["amp"]; got == held {
+		t.Errorf("amp in the next cycle = %g, want a new sample", got)
+	}
+}
+
+func TestModulatorRandomWalkScalesWithTime(t *testing.T) {
+	target := &fakeTarget{values: map[string]float64{"amp": 0.5}}
+	mod := NewModulator(target, "amp", 1)
+	mod.Shape = RandomWalk
+	mod.Rate = 1
+	mod.Depth = 1
+
+	// No time passing means no step, however often it ticks
+	for range 10 {
+		mod.Tick(epoch)
+	}
+	if got := target.values["amp"]; got != 0.5 {
+		t.Fatalf("amp after ticks at one instant = %g, want 0.5", got)
+	}
+
+	// Each step is at most rate * walkSpeed * dt (half the range at depth 1)
+	previous := 0.5
+	now := epoch
+	for range 100 {
+		now = now.Add(10 * time.Millisecond)
+		mod.Tick(now)
+		got := target.values["amp"]
+		if step := math.Abs(got - previous); step > walkSpeed*0.01/2+1e-9 {
+			t.Fatalf("step of %g in 10ms, want at most %g", step, walkSpeed*0.01/2)
+		}
+		if got < 0 || got > 1 {
+			t.Fatalf("amp = %g, want within the 0-1 range", got)
+		}
+		previous = got
+	}
+}
+
+func TestModulatorDepthClampsToBounds(t *testing.T) {
+	// Full depth around 0.9 would reach 1.4 at the peak
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   float64
+	}{
+		{"peak clamps to max", 250 * time.Millisecond, 1},
+		{"trough within range", 750 * time.Millisecond, 0.4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &fakeTarget{values: map[string]float64{"amp": 0.9}}
+			mod := NewModulator(target, "amp", 1)
+			mod.Rate = 1
+			mod.Depth = 1
+
+			mod.Tick(epoch)
+			mod.Tick(epoch.Add(tt.offset))
+			if got := target.values["amp"]; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("amp = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModulatorRelease(t *testing.T) {
+	mod, target := modulate(Sine, 0, 0.3, 250*time.Millisecond)
+	if got := target.values["amp"]; got == 0.3 {
+		t.Fatalf("amp = %g, want it moved from the centre", got)
+	}
+
+	mod.Release()
+	if got := target.values["amp"]; got != 0.3 {
+		t.Errorf("amp after Release = %g, want the centre 0.3", got)
+	}
+	if got := mod.Center(); got != 0.3 {
+		t.Errorf("Center = %g, want 0.3", got)
+	}
+}
@@ -0,0 +1,184 @@
+package automation
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"forbidden_sequencer/controllers"
+)
+
+// Target is a controller whose parameters can be automated
+// Implemented by controllers.ParamController
+type Target interface {
+	// Param returns the descriptor for a parameter name, or nil
+	Param(name string) *controllers.Param
+
+	// Value returns the current value of a parameter
+	Value(name string) float64
+
+	// Limits returns a parameter's current min and max
+	Limits(name string) (min, max float64)
+
+	// SetValue clamps, stores and sends a value; returns true if it changed
+	SetValue(name string, v float64) bool
+
+	// PhraseDuration returns the pattern's phrase length in seconds (0 if unknown)
+	PhraseDuration() float64
+}
+
+// LengthUnit is the unit a morph length is expressed in
+type LengthUnit int
+
+const (
+	Phrases LengthUnit = iota
+	Seconds
+)
+
+// String returns the unit name for display
+func (u LengthUnit) String() string {
+	if u == Seconds {
+		return "seconds"
+	}
+	return "phrases"
+}
+
+// ErrNoPhraseLength is returned for a morph measured in phrases of a pattern
+// that doesn't know its phrase length
+var ErrNoPhraseLength = errors.New("pattern has no phrase length")
+
+// Morph glides a target from one snapshot to another
+// Continuous parameters are interpolated on every tick; integer and flag
+// parameters only move at phrase boundaries so the pattern stays coherent
+//
+// Those boundaries are counted from the start of the morph, not from the
+// pattern's own phrase: sclang doesn't report where it is in a phrase, so
+// there is no phrase clock to align to. This keeps one step per phrase at
+// the right rate, and patterns that buffer structural changes (curve_time's
+// phrase events) apply each step at their next real phrase start anyway
+type Morph struct {
+	target    Target
+	from      map[string]float64
+	to        map[string]float64
+	total     time.Duration // full morph length
+	phraseDur time.Duration // phrase length used for integer steps (0 steps continuously)
+	elapsed   time.Duration // running time so far, excluding pauses
+	lastTick  time.Time
+	paused    bool
+	done      bool
+}
+
+// NewMorph creates a morph from one snapshot to another over length units
+// The phrase length is measured once, at the start
+// Returns ErrNoPhraseLength for phrases if the target's phrase length is unknown
+func NewMorph(target Target, from, to map[string]float64, length float64, unit LengthUnit) (*Morph, error) {
+	phraseDur := time.Duration(target.PhraseDuration() * float64(time.Second))
+
+	total := time.Duration(length * float64(time.Second))
+	if unit == Phrases {
+		if phraseDur <= 0 {
+			return nil, ErrNoPhraseLength
+		}
+		total = time.Duration(length * float64(phraseDur))
+	}
+
+	return &Morph{
+		target:    target,
+		from:      from,
+		to:        to,
+		total:     total,
+		phraseDur: phraseDur,
+	}, nil
+}
+
+// Tick advances the morph to now and sends any changed values
+func (m *Morph) Tick(now time.Time) {
+	if m.done {
+		return
+	}
+
+	if !m.lastTick.IsZero() && !m.paused {
+		m.elapsed += now.Sub(m.lastTick)
+	}
+	m.lastTick = now
+
+	if m.total <= 0 || m.elapsed >= m.total {
+		m.apply(1, 1)
+		m.done = true
+		return
+	}
+
+	// Continuous progress, and progress at the last phrase boundary since
+	// the morph started
+	progress := float64(m.elapsed) / float64(m.total)
+	stepped := progress
+	if m.phraseDur > 0 {
+		boundary := m.elapsed.Truncate(m.phraseDur)
+		stepped = float64(boundary) / float64(m.total)
+	}
+
+	m.apply(progress, stepped)
+}
+
+// apply sets every morphed parameter for the given progress values
+func (m *Morph) apply(progress, stepped float64) {
+	for name, to := range m.to {
+		from, ok := m.from[name]
+		p := m.target.Param(name)
+		if !ok || p == nil {
+			continue
+		}
+
+		t := progress
+		if !p.Continuous() {
+			t = stepped
+		}
+
+		v := from + (to-from)*t
+		if !p.Continuous() {
+			v = math.Round(v)
+		}
+		m.target.SetValue(name, v)
+	}
+}
+
+// Pause freezes the morph at its current position
+func (m *Morph) Pause() {
+	m.paused = true
+}
+
+// Resume continues a paused morph
+func (m *Morph) Resume() {
+	m.paused = false
+}
+
+// Paused reports whether the morph is paused
+func (m *Morph) Paused() bool {
+	return m.paused
+}
+
+// Abort stops the morph, leaving parameters where they are
+func (m *Morph) Abort() {
+	m.done = true
+}
+
+// Done reports whether the morph has finished or been aborted
+func (m *Morph) Done() bool {
+	return m.done
+}
+
+// Progress returns how far through the morph we are (0-1)
+func (m *Morph) Progress() float64 {
+	if m.total <= 0 {
+		return 1
+	}
+	return math.Min(1, float64(m.elapsed)/float64(m.total))
+}
+
+// Remaining returns the time left until the morph completes
+func (m *Morph) Remaining() time.Duration {
+	if m.elapsed >= m.total {
+		return 0
+	}
+	return m.total - m.elapsed
+}
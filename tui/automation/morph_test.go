@@ -0,0 +1,153 @@
+package automation
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"forbidden_sequencer/controllers"
+)
+
+// fakeTarget is a Target with continuous parameters (integer ones if named in
+// ints) and a fixed phrase length
+type fakeTarget struct {
+	phrase float64
+	values map[string]float64
+	ints   map[string]bool
+}
+
+func (f *fakeTarget) Param(name string) *controllers.Param {
+	if f.ints[name] {
+		return &controllers.Param{Name: name, Type: controllers.ParamInt, Max: 16}
+	}
+	return &controllers.Param{Name: name, Type: controllers.ParamFloat, Max: 1}
+}
+func (f *fakeTarget) Value(name string) float64             { return f.values[name] }
+func (f *fakeTarget) Limits(name string) (float64, float64) { return 0, 1 }
+func (f *fakeTarget) PhraseDuration() float64               { return f.phrase }
+func (f *fakeTarget) SetValue(name string, v float64) bool  { f.values[name] = v; return true }
+
+func TestNewMorphWithoutPhraseLength(t *testing.T) {
+	target := &fakeTarget{values: map[string]float64{}}
+	from, to := map[string]float64{"amp": 0}, map[string]float64{"amp": 1}
+
+	if _, err := NewMorph(target, from, to, 4, Phrases); !errors.Is(err, ErrNoPhraseLength) {
+		t.Fatalf("morph over phrases without a phrase length: err = %v, want ErrNoPhraseLength", err)
+	}
+
+	morph, err := NewMorph(target, from, to, 4, Seconds)
+	if err != nil {
+		t.Fatalf("morph over seconds failed: %v", err)
+	}
+	if got := morph.Remaining(); got != 4*time.Second {
+		t.Errorf("Remaining = %v, want 4s", got)
+	}
+}
+
+func TestNewMorphOverPhrases(t *testing.T) {
+	target := &fakeTarget{phrase: 2, values: map[string]float64{}}
+	morph, err := NewMorph(target, map[string]float64{"amp": 0}, map[string]float64{"amp": 1}, 3, Phrases)
+	if err != nil {
+		t.Fatalf("NewMorph failed: %v", err)
+	}
+	if got := morph.Remaining(); got != 6*time.Second {
+		t.Errorf("Remaining = %v, want 6s", got)
+	}
+
+	start := time.Now()
+	morph.Tick(start)
+	morph.Tick(start.Add(3 * time.Second))
+	if got := target.values["amp"]; got != 0.5 {
+		t.Errorf("amp halfway = %g, want 0.5", got)
+	}
+}
+
+func TestMorphStepsIntegersAtPhraseBoundaries(t *testing.T) {
+	// 4 phrases of 1s, 0 to 8 events: +2 at each boundary
+	target := &fakeTarget{phrase: 1, values: map[string]float64{}, ints: map[string]bool{"events": true}}
+	from := map[string]float64{"amp": 0, "events": 0}
+	to := map[string]float64{"amp": 1, "events": 8}
+	morph, err := NewMorph(target, from, to, 4, Phrases)
+	if err != nil {
+		t.Fatalf("NewMorph failed: %v", err)
+	}
+
+	tests := []struct {
+		at     time.Duration
+		amp    float64
+		events float64
+	}{
+		{0, 0, 0},
+		{500 * time.Millisecond, 0.125, 0},
+		{999 * time.Millisecond, 0.24975, 0},
+		{1000 * time.Millisecond, 0.25, 2},
+		{1900 * time.Millisecond, 0.475, 2},
+		{2000 * time.Millisecond, 0.5, 4},
+		{3500 * time.Millisecond, 0.875, 6},
+		{4000 * time.Millisecond, 1, 8},
+	}
+
+	start := time.Now()
+	for _, tt := range tests {
+		morph.Tick(start.Add(tt.at))
+		if got := target.values["amp"]; math.Abs(got-tt.amp) > 1e-9 {
+			t.Errorf("at %v: amp = %g, want %g", tt.at, got, tt.amp)
+		}
+		if got := target.values["events"]; got != tt.events {
+			t.Errorf("at %v: events = %g, want %g", tt.at, got, tt.events)
+		}
+	}
+	if !morph.Done() {
+		t.Error("morph not done at its length")
+	}
+}
+
+func TestMorphPauseResume(t *testing.T) {
+	target := &fakeTarget{values: map[string]float64{}}
+	morph, err := NewMorph(target, map[string]float64{"amp": 0}, map[string]float64{"amp": 1}, 4, Seconds)
+	if err != nil {
+		t.Fatalf("NewMorph failed: %v", err)
+	}
+
+	start := time.Now()
+	morph.Tick(start)
+	morph.Tick(start.Add(time.Second))
+	morph.Pause()
+
+	// Time spent paused doesn't count
+	morph.Tick(start.Add(10 * time.Second))
+	if got := target.values["amp"]; got != 0.25 {
+		t.Errorf("amp while paused = %g, want 0.25", got)
+	}
+	if got := morph.Remaining(); got != 3*time.Second {
+		t.Errorf("Remaining while paused = %v, want 3s", got)
+	}
+
+	morph.Resume()
+	morph.Tick(start.Add(11 * time.Second))
+	if got := target.values["amp"]; got != 0.5 {
+		t.Errorf("amp a second after resuming = %g, want 0.5", got)
+	}
+}
+
+func TestMorphAbortKeepsValues(t *testing.T) {
+	target := &fakeTarget{values: map[string]float64{}}
+	morph, err := NewMorph(target, map[string]float64{"amp": 0}, map[string]float64{"amp": 1}, 4, Seconds)
+	if err != nil {
+		t.Fatalf("NewMorph failed: %v", err)
+	}
+
+	start := time.Now()
+	morph.Tick(start)
+	morph.Tick(start.Add(2 * time.Second))
+	morph.Abort()
+
+	if !morph.Done() {
+		t.Error("morph not done after Abort")
+	}
+	morph.Tick(start.Add(4 * time.Second))
+	if got := target.values["amp"]; got != 0.5 {
+		t.Errorf("amp after Abort = %g, want it left at 0.5", got)
+	}
+}
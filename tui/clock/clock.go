@@ -0,0 +1,188 @@
+package clock
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Tempo limits
+const (
+	MinBPM     = 20.0
+	MaxBPM     = 300.0
+	DefaultBPM = 120.0
+
+	DefaultSubdivision = 4 // sixteenth notes
+)
+
+// Event duration limits, matching the base_event_dur parameter of the patterns
+// Slow tempos at coarse subdivisions would exceed MaxEventDur, so the
+// lowest BPM depends on the subdivision (see BPMRange)
+const (
+	MinEventDur = 0.025
+	MaxEventDur = 1.0
+)
+
+// Tap tempo and nudge tuning
+const (
+	tapTimeout  = 2 * time.Second        // a longer gap starts a new tap sequence
+	maxTaps     = 8                      // taps averaged for the tempo
+	nudgeAmount = 0.04                   // nudge speeds up or slows down by 4%
+	nudgeLength = 250 * time.Millisecond // how long one nudge keypress lasts
+)
+
+// subdivisions are the selectable events per beat, with their note names
+var subdivisions = []struct {
+	perBeat int
+	name    string
+}{
+	{1, "1/4"},
+	{2, "1/8"},
+	{3, "1/8T"},
+	{4, "1/16"},
+	{6, "1/16T"},
+	{8, "1/32"},
+}
+
+// Clock is the global tempo shared by every pattern
+// Patterns play one event per subdivision, so the event duration sent as
+// base_event_dur is 60 / BPM / subdivision
+type Clock struct {
+	bpm         float64
+	subdivision int // events per beat
+
+	taps       []time.Time
+	nudge      float64 // temporary tempo offset while nudging (e.g. +0.04)
+	nudgeUntil time.Time
+}
+
+// New creates a clock, falling back to defaults for invalid values
+func New(bpm float64, subdivision int) *Clock {
+	c := &Clock{subdivision: DefaultSubdivision}
+	for _, s := range subdivisions {
+		if s.perBeat == subdivision {
+			c.subdivision = subdivision
+		}
+	}
+	if bpm <= 0 {
+		bpm = DefaultBPM
+	}
+	c.SetBPM(bpm)
+	return c
+}
+
+// BPM returns the tempo in beats per minute (excluding any nudge)
+func (c *Clock) BPM() float64 {
+	return c.bpm
+}
+
+// SetBPM sets the tempo, clamped to BPMRange and rounded to 0.1 BPM
+func (c *Clock) SetBPM(bpm float64) {
+	low, high := c.BPMRange()
+	c.bpm = math.Round(math.Max(low, math.Min(high, bpm))*10) / 10
+}
+
+// BPMRange returns the tempos the current subdivision can play with event
+// durations within MinEventDur and MaxEventDur
+func (c *Clock) BPMRange() (low, high float64) {
+	perBeat := float64(c.subdivision)
+	return math.Max(MinBPM, 60/(MaxEventDur*perBeat)), math.Min(MaxBPM, 60/(MinEventDur*perBeat))
+}
+
+// Subdivision returns the number of events per beat
+func (c *Clock) Subdivision() int {
+	return c.subdivision
+}
+
+// NextSubdivision cycles to the next subdivision
+// The tempo is raised if it is too slow for the new subdivision
+func (c *Clock) NextSubdivision() {
+	next := DefaultSubdivision
+	for i, s := range subdivisions {
+		if s.perBeat == c.subdivision {
+			next = subdivisions[(i+1)%len(subdivisions)].perBeat
+			break
+		}
+	}
+	c.subdivision = next
+	c.SetBPM(c.bpm)
+}
+
+// SubdivisionName returns the note value of one event (e.g. "1/16")
+func (c *Clock) SubdivisionName() string {
+	for _, s := range subdivisions {
+		if s.perBeat == c.subdivision {
+			return s.name
+		}
+	}
+	return fmt.Sprintf("1/%d beat", c.subdivision)
+}
+
+// EventDur returns the duration of one event in seconds, including any nudge
+// A nudge past MinEventDur or MaxEventDur stops at the limit
+func (c *Clock) EventDur() float64 {
+	dur := 60 / (c.bpm * (1 + c.nudge)) / float64(c.subdivision)
+	return math.Max(MinEventDur, math.Min(MaxEventDur, dur))
+}
+
+// BarDuration returns the length of a 4/4 bar in seconds (excluding any nudge)
+func (c *Clock) BarDuration() float64 {
+	return 4 * 60 / c.bpm
+}
+
+// Tap registers a tap-tempo keypress
+// Returns true once enough taps have been collected to set the tempo
+func (c *Clock) Tap(now time.Time) bool {
+	if len(c.taps) > 0 && now.Sub(c.taps[len(c.taps)-1]) > tapTimeout {
+		c.taps = nil
+	}
+	c.taps = append(c.taps, now)
+	if len(c.taps) > maxTaps {
+		c.taps = c.taps[len(c.taps)-maxTaps:]
+	}
+	if len(c.taps) < 2 {
+		return false
+	}
+
+	// Average interval across the kept taps
+	interval := c.taps[len(c.taps)-1].Sub(c.taps[0]) / time.Duration(len(c.taps)-1)
+	c.SetBPM(60 / interval.Seconds())
+	return true
+}
+
+// Nudge briefly speeds up (direction > 0) or slows down (direction < 0) the
+// tempo to shift the groove against another source; the tempo returns once
+// the nudge expires (see Tick)
+func (c *Clock) Nudge(direction int, now time.Time) {
+	c.nudge = nudgeAmount
+	if direction < 0 {
+		c.nudge = -nudgeAmount
+	}
+	c.nudgeUntil = now.Add(nudgeLength)
+}
+
+// Nudging reports whether a nudge is active
+func (c *Clock) Nudging() bool {
+	return c.nudge != 0
+}
+
+// Tick ends an expired nudge; returns true if the event duration changed
+func (c *Clock) Tick(now time.Time) bool {
+	if c.nudge != 0 && !now.Before(c.nudgeUntil) {
+		c.nudge = 0
+		return true
+	}
+	return false
+}
+
+// Describe summarises the tempo (e.g. "120.0 BPM, 1/16 (0.125s)")
+func (c *Clock) Describe() string {
+	desc := fmt.Sprintf("%.1f BPM, %s (%.3fs)", c.bpm, c.SubdivisionName(), c.EventDur())
+	switch {
+	case c.nudge > 0:
+		desc += " nudge +"
+	case c.nudge < 0:
+		desc += " nudge -"
+	}
+	return desc
+}
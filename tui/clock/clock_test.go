@@ -0,0 +1,120 @@
+package clock
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSetBPM(t *testing.T) {
+	tests := []struct {
+		name        string
+		subdivision int
+		bpm         float64
+		want        float64
+	}{
+		{"in range", 4, 128, 128},
+		{"rounded to 0.1", 4, 127.46, 127.5},
+		{"below minimum", 4, 5, MinBPM},
+		{"above maximum", 4, 999, MaxBPM},
+		{"quarter notes can't exceed 1s events", 1, 40, 60},
+		{"eighth notes can't exceed 1s events", 2, 20, 30},
+		{"triplet eighths reach the minimum", 3, 20, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(DefaultBPM, tt.subdivision)
+			c.SetBPM(tt.bpm)
+			if got := c.BPM(); got != tt.want {
+				t.Errorf("BPM = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name            string
+		bpm             float64
+		subdivision     int
+		wantBPM         float64
+		wantSubdivision int
+	}{
+		{"defaults for zero values", 0, 0, DefaultBPM, DefaultSubdivision},
+		{"unknown subdivision", 90, 5, 90, DefaultSubdivision},
+		{"slow tempo clamped for its subdivision", 25, 1, 60, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(tt.bpm, tt.subdivision)
+			if c.BPM() != tt.wantBPM || c.Subdivision() != tt.wantSubdivision {
+				t.Errorf("New = %g BPM at %d, want %g at %d", c.BPM(), c.Subdivision(), tt.wantBPM, tt.wantSubdivision)
+			}
+		})
+	}
+}
+
+func TestNextSubdivision(t *testing.T) {
+	c := New(DefaultBPM, 1)
+	want := []string{"1/8", "1/8T", "1/16", "1/16T", "1/32", "1/4", "1/8"}
+	for _, name := range want {
+		c.NextSubdivision()
+		if got := c.SubdivisionName(); got != name {
+			t.Fatalf("SubdivisionName = %s, want %s", got, name)
+		}
+	}
+
+	// Cycling back to quarter notes raises a tempo too slow for them
+	c = New(25, 8) // 1/32
+	c.NextSubdivision()
+	if c.Subdivision() != 1 || c.BPM() != 60 {
+		t.Errorf("after cycling to 1/4 at 25 BPM: %g BPM at %d, want 60 at 1", c.BPM(), c.Subdivision())
+	}
+}
+
+func TestEventDur(t *testing.T) {
+	tests := []struct {
+		bpm         float64
+		subdivision int
+		want        float64
+	}{
+		{120, 4, 0.125},
+		{120, 1, 0.5},
+		{60, 1, 1},
+		{20, 4, 0.75},
+		{300, 8, 0.025},
+		{90, 3, 60.0 / 90 / 3},
+	}
+
+	for _, tt := range tests {
+		c := New(tt.bpm, tt.subdivision)
+		if got := c.EventDur(); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("EventDur at %g BPM, %d per beat = %g, want %g", tt.bpm, tt.subdivision, got, tt.want)
+		}
+		if got := c.EventDur(); got < MinEventDur || got > MaxEventDur {
+			t.Errorf("EventDur %g outside the pattern limits", got)
+		}
+	}
+}
+
+func TestNudgeStaysWithinEventLimits(t *testing.T) {
+	now := time.Now()
+
+	slow := New(60, 1)
+	slow.Nudge(-1, now)
+	if got := slow.EventDur(); got != MaxEventDur {
+		t.Errorf("nudged slower at the slowest tempo: EventDur = %g, want %g", got, MaxEventDur)
+	}
+
+	fast := New(MaxBPM, 8)
+	fast.Nudge(1, now)
+	if got := fast.EventDur(); got != MinEventDur {
+		t.Errorf("nudged faster at the fastest tempo: EventDur = %g, want %g", got, MinEventDur)
+	}
+
+	if !fast.Tick(now.Add(nudgeLength)) || fast.Nudging() {
+		t.Error("nudge didn't expire after nudgeLength")
+	}
+}
@@ -1,6 +1,9 @@
 package controllers
 
-import tea "github.com/charmbracelet/bubbletea"
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hypebeast/go-osc/osc"
+)
 
 // Controller represents a pattern controller that manages interaction with sclang
 type Controller interface {
@@ -20,3 +23,68 @@ type Controller interface {
 	// Quit cleans up and stops the pattern
 	Quit()
 }
+
+// Presettable is implemented by controllers whose parameters can be stored as presets
+type Presettable interface {
+	// ID returns a stable identifier used to store the controller's presets
+	ID() string
+
+	// Snapshot returns the current value of every stored parameter
+	Snapshot() map[string]float64
+
+	// Restore applies a snapshot and sends every value to sclang
+	Restore(snapshot map[string]float64)
+}
+
+// OSCReceiver is implemented by controllers that react to messages sent back by sclang
+type OSCReceiver interface {
+	// HandleOSC processes an incoming OSC message
+	// Returns true if the message was addressed to this controller
+	HandleOSC(msg *osc.Message) bool
+}
+
+// Layerable is implemented by controllers that can run as one of several layers
+// Layers are played, stopped and muted from the layer list, not just by key
+type Layerable interface {
+	// IsPlaying reports whether the pattern is playing
+	IsPlaying() bool
+
+	// Play starts the pattern from the beginning
+	Play()
+
+	// Stop stops the pattern and rewinds it
+	Stop()
+
+	// Muted reports whether the pattern is silenced
+	Muted() bool
+
+	// SetMuted silences the pattern while it keeps running, or unmutes it
+	SetMuted(muted bool)
+}
+
+// TempoFollower is implemented by controllers whose event duration follows the global clock
+type TempoFollower interface {
+	// SetEventDuration sets the duration of one event in seconds
+	SetEventDuration(seconds float64)
+}
+
+// TimelineRenderer is implemented by controllers that can draw their phrase
+type TimelineRenderer interface {
+	// Timeline renders the phrase across the given number of columns
+	Timeline(width int) string
+}
+
+// Summarizer is implemented by controllers that can describe their state in one short line
+type Summarizer interface {
+	// Summary returns the most important settings, e.g. for the main view
+	Summary() string
+}
+
+// transportStateArg extracts the transport state string from a /transport reply
+func transportStateArg(msg *osc.Message) (string, bool) {
+	if len(msg.Arguments) < 1 {
+		return "", false
+	}
+	state, ok := msg.Arguments[0].(string)
+	return state, ok
+}
@@ -2,270 +2,132 @@ package controllers
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"forbidden_sequencer/adapter"
-
-	tea "github.com/charmbracelet/bubbletea"
 )
 
 // CurveTimeController controls the curve_time pattern in sclang via OSC
 type CurveTimeController struct {
-	sclangAdapter *adapter.OSCAdapter
-	baseEventDur  float64
-	phraseEvents  int
-	kickCurve     float64
-	kickEvents    int
-	kickOffset    int
-	hihatCurve    float64
-	hihatEvents   int
-	hihatOffset   int
-	debug         bool
-	isPlaying     bool
-	activeSynth   int // 0=phrase, 1=kick, 2=hihat
-}
-
-// NewCurveTimeController creates a new curve time controller
-func NewCurveTimeController(sclangAdapter *adapter.OSCAdapter) *CurveTimeController {
-	return &CurveTimeController{
-		sclangAdapter: sclangAdapter,
-		baseEventDur:  0.125,
-		phraseEvents:  16,
-		kickCurve:     1.5,
-		kickEvents:    8,
-		kickOffset:    0,
-		hihatCurve:    1.5,
-		hihatEvents:   8,
-		hihatOffset:   0,
-		debug:         false,
-		isPlaying:     false,
-		activeSynth:   1, // Start with kick selected
-	}
+	*ParamController
 }
 
-// GetName returns the display name
-func (c *CurveTimeController) GetName() string {
-	return "Curve Time"
+// offsetBounds limits a voice offset to within one phrase
+func offsetBounds(values map[string]float64) (float64, float64) {
+	span := values["phrase_events"] - 1
+	return -span, span
 }
 
-// GetKeybindings returns the controller-specific controls
-func (c *CurveTimeController) GetKeybindings() string {
-	return `p: play/stop
-space: pause/resume
-1-2: select synth
-d/D: base event dur
-r/R: phrase events
-c/C: curve (active synth)
-e/E: events (active synth)
-o/O: offset (active synth)
-x: debug`
+// curveTimeMix are the default amp and reverb send of each voice
+var curveTimeMix = map[string][2]float64{
+	"kick":  {0.8, 0},
+	"hihat": {0.6, 0},
 }
 
-// GetStatus returns the current state
-func (c *CurveTimeController) GetStatus() string {
-	var status strings.Builder
-
-	// Phrase state
-	phraseDur := float64(c.phraseEvents) * c.baseEventDur
-	status.WriteString(fmt.Sprintf("Base: %.3fs, Events: %d, Phrase: %.2fs\n\n", c.baseEventDur, c.phraseEvents, phraseDur))
-
-	// Synths section
-	status.WriteString("Synths:\n")
-
-	// Kick state (highlight if active)
-	kickPrefix := "  "
-	if c.activeSynth == 1 {
-		kickPrefix = "> "
+// curveTimeSchema declares the curve_time parameters (defaults match curve_time.scd)
+func curveTimeSchema() Schema {
+	params := []*Param{
+		{Name: "base_event_dur", Label: "Base", Help: "base event dur", Type: ParamFloat, Min: 0.025, Max: 1.0, Step: 0.005, Default: 0.125, Format: FormatFloat(3, "s")},
+		{Name: "phrase_events", Label: "Events", Help: "phrase events", Type: ParamInt, Min: 16, Max: 32, Step: 1, Default: 16, DecKey: "r", IncKey: "R"},
 	}
-	status.WriteString(fmt.Sprintf("%s1. Kick: curve=%.1f, events=%d, offset=%+d\n", kickPrefix, c.kickCurve, c.kickEvents, c.kickOffset))
 
-	// Hihat state (highlight if active)
-	hihatPrefix := "  "
-	if c.activeSynth == 2 {
-		hihatPrefix = "> "
+	for _, voice := range []string{"kick", "hihat"} {
+		params = append(params,
+			&Param{Name: voice + "/curve", Label: "curve", Help: "curve", Type: ParamFloat, Min: 0.5, Max: 2.0, Step: 0.1, Default: 1.5, Voice: voice, DecKey: "c", IncKey: "C", Format: FormatFloat(1, "")},
+			&Param{Name: voice + "/events", Label: "events", Help: "events", Type: ParamInt, Min: 1, Max: 16, Step: 1, Default: 8, Voice: voice, DecKey: "e", IncKey: "E"},
+			&Param{Name: voice + "/offset", Label: "offset", Help: "offset", Type: ParamInt, Step: 1, Default: 0, Voice: voice, DecKey: "o", IncKey: "O", Format: FormatSigned, Bounds: offsetBounds},
+		)
+		params = append(params, MixParams(voice, curveTimeMix[voice][0], curveTimeMix[voice][1])...)
 	}
-	status.WriteString(fmt.Sprintf("%s2. Hihat: curve=%.1f, events=%d, offset=%+d", hihatPrefix, c.hihatCurve, c.hihatEvents, c.hihatOffset))
 
-	if c.debug {
-		status.WriteString("\nDEBUG")
+	params = append(params, &Param{Name: "debug", Label: "debug", Help: "debug", Type: ParamBool, DecKey: "x", Transient: true})
+
+	return Schema{
+		Name:      "Curve Time",
+		Namespace: "/pattern/curve_time",
+		Voices: []Voice{
+			{Name: "kick", Label: "Kick"},
+			{Name: "hihat", Label: "Hihat"},
+		},
+		Params:      params,
+		TempoParam:  "base_event_dur",
+		PhraseParam: "phrase_events",
 	}
-
-	return status.String()
 }
 
-// HandleInput processes controller-specific input
-func (c *CurveTimeController) HandleInput(msg tea.KeyMsg) bool {
-	switch msg.String() {
-	case " ":
-		// Toggle pause/resume
-		if c.isPlaying {
-			c.sclangAdapter.Send("/pattern/curve_time/pause")
-			c.isPlaying = false
-		} else {
-			c.sclangAdapter.Send("/pattern/curve_time/resume")
-			c.isPlaying = true
-		}
-		return true
-
-	case "p":
-		// Toggle play/stop (reset position)
-		if c.isPlaying {
-			c.sclangAdapter.Send("/pattern/curve_time/stop")
-			c.isPlaying = false
-		} else {
-			c.sclangAdapter.Send("/pattern/curve_time/play")
-			c.isPlaying = true
-		}
-		return true
-
-	case "1":
-		// Select kick synth
-		c.activeSynth = 1
-		return true
-
-	case "2":
-		// Select hihat synth
-		c.activeSynth = 2
-		return true
-
-	case "d":
-		// Decrease base event duration
-		if c.baseEventDur > 0.025 {
-			c.baseEventDur -= 0.005
-			c.sclangAdapter.Send("/pattern/curve_time/base_event_dur", float32(c.baseEventDur))
-		}
-		return true
-
-	case "D":
-		// Increase base event duration
-		if c.baseEventDur < 1.0 {
-			c.baseEventDur += 0.005
-			c.sclangAdapter.Send("/pattern/curve_time/base_event_dur", float32(c.baseEventDur))
-		}
-		return true
-
-	case "r":
-		// Decrease phrase events
-		if c.phraseEvents > 16 {
-			c.phraseEvents--
-			c.sclangAdapter.Send("/pattern/curve_time/phrase_events", int32(c.phraseEvents))
-		}
-		return true
-
-	case "R":
-		// Increase phrase events
-		if c.phraseEvents < 32 {
-			c.phraseEvents++
-			c.sclangAdapter.Send("/pattern/curve_time/phrase_events", int32(c.phraseEvents))
-		}
-		return true
-
-	case "c":
-		// Decrease curve for active synth
-		if c.activeSynth == 1 {
-			if c.kickCurve > 0.5 {
-				c.kickCurve -= 0.1
-				c.sclangAdapter.Send("/pattern/curve_time/kick/curve", float32(c.kickCurve))
-			}
-		} else if c.activeSynth == 2 {
-			if c.hihatCurve > 0.5 {
-				c.hihatCurve -= 0.1
-				c.sclangAdapter.Send("/pattern/curve_time/hihat/curve", float32(c.hihatCurve))
-			}
-		}
-		return true
-
-	case "C":
-		// Increase curve for active synth
-		if c.activeSynth == 1 {
-			if c.kickCurve < 2.0 {
-				c.kickCurve += 0.1
-				c.sclangAdapter.Send("/pattern/curve_time/kick/curve", float32(c.kickCurve))
-			}
-		} else if c.activeSynth == 2 {
-			if c.hihatCurve < 2.0 {
-				c.hihatCurve += 0.1
-				c.sclangAdapter.Send("/pattern/curve_time/hihat/curve", float32(c.hihatCurve))
-			}
-		}
-		return true
-
-	case "e":
-		// Decrease events for active synth
-		if c.activeSynth == 1 {
-			if c.kickEvents > 1 {
-				c.kickEvents--
-				c.sclangAdapter.Send("/pattern/curve_time/kick/events", int32(c.kickEvents))
-			}
-		} else if c.activeSynth == 2 {
-			if c.hihatEvents > 1 {
-				c.hihatEvents--
-				c.sclangAdapter.Send("/pattern/curve_time/hihat/events", int32(c.hihatEvents))
-			}
-		}
-		return true
+// NewCurveTimeController creates a new curve time controller
+func NewCurveTimeController(sclangAdapter *adapter.OSCAdapter) *CurveTimeController {
+	return &CurveTimeController{
+		ParamController: NewParamController(curveTimeSchema(), sclangAdapter),
+	}
+}
 
-	case "E":
-		// Increase events for active synth
-		if c.activeSynth == 1 {
-			if c.kickEvents < 16 {
-				c.kickEvents++
-				c.sclangAdapter.Send("/pattern/curve_time/kick/events", int32(c.kickEvents))
-			}
-		} else if c.activeSynth == 2 {
-			if c.hihatEvents < 16 {
-				c.hihatEvents++
-				c.sclangAdapter.Send("/pattern/curve_time/hihat/events", int32(c.hihatEvents))
-			}
-		}
-		return true
+// CurveEvent is one event slot of a curve-time voice
+type CurveEvent struct {
+	Time   float64 // position in the phrase (0-1)
+	Active bool    // inside the voice's event window, so it sounds
+}
 
-	case "o":
-		// Decrease offset for active synth
-		if c.activeSynth == 1 {
-			if c.kickOffset > -(c.phraseEvents - 1) {
-				c.kickOffset--
-				c.sclangAdapter.Send("/pattern/curve_time/kick/offset", int32(c.kickOffset))
-			}
-		} else if c.activeSynth == 2 {
-			if c.hihatOffset > -(c.phraseEvents - 1) {
-				c.hihatOffset--
-				c.sclangAdapter.Send("/pattern/curve_time/hihat/offset", int32(c.hihatOffset))
-			}
+// CurveEvents computes a voice's event slots the same way curve_time.scd does:
+// slot i of n sits at (i/(n-1))^curve through the phrase, and the voice
+// sounds on the first events slots counted from its offset
+func (c *CurveTimeController) CurveEvents(voice string) []CurveEvent {
+	n := int(c.Value("phrase_events"))
+	curve := c.Value(voice + "/curve")
+	events := int(c.Value(voice + "/events"))
+	offset := int(c.Value(voice + "/offset"))
+
+	slots := make([]CurveEvent, n)
+	for i := range slots {
+		t := 0.0
+		if n > 1 {
+			t = float64(i) / float64(n-1)
 		}
-		return true
+		offsetPos := ((i-offset)%n + n) % n
+		slots[i] = CurveEvent{Time: math.Pow(t, curve), Active: offsetPos < events}
+	}
+	return slots
+}
 
-	case "O":
-		// Increase offset for active synth
-		if c.activeSynth == 1 {
-			if c.kickOffset < (c.phraseEvents - 1) {
-				c.kickOffset++
-				c.sclangAdapter.Send("/pattern/curve_time/kick/offset", int32(c.kickOffset))
-			}
-		} else if c.activeSynth == 2 {
-			if c.hihatOffset < (c.phraseEvents - 1) {
-				c.hihatOffset++
-				c.sclangAdapter.Send("/pattern/curve_time/hihat/offset", int32(c.hihatOffset))
+// Timeline draws the phrase grid and each voice's event positions
+// ● marks events that sound, ○ slots outside the event window
+func (c *CurveTimeController) Timeline(width int) string {
+	const labelWidth = 8
+	lines := []string{}
+
+	// Even grid of phrase events
+	grid := []rune(strings.Repeat("·", width))
+	n := int(c.Value("phrase_events"))
+	for i := 0; i < n; i++ {
+		grid[i*width/n] = '|'
+	}
+	lines = append(lines, fmt.Sprintf("%-*s%s", labelWidth, "grid", string(grid)))
+
+	// Curved event positions per voice
+	active := c.ActiveVoice().Name
+	for _, voice := range c.Voices() {
+		row := []rune(strings.Repeat(" ", width))
+		for _, event := range c.CurveEvents(voice.Name) {
+			col := min(width-1, int(event.Time*float64(width)))
+			switch {
+			case event.Active:
+				row[col] = '●'
+			case row[col] != '●':
+				row[col] = '○'
 			}
 		}
-		return true
 
-	case "x":
-		// Toggle debug
-		c.debug = !c.debug
-		debugInt := int32(0)
-		if c.debug {
-			debugInt = 1
+		label := voice.Label
+		if voice.Name == active {
+			label = "> " + label
 		}
-		c.sclangAdapter.Send("/pattern/curve_time/debug", debugInt)
-		return true
+		lines = append(lines, fmt.Sprintf("%-*s%s", labelWidth, label, string(row)))
 	}
 
-	return false
-}
+	// Time axis
+	end := fmt.Sprintf("%.2fs", c.PhraseDuration())
+	lines = append(lines, fmt.Sprintf("%-*s0%*s", labelWidth, "", width-1, end))
 
-// Quit stops the pattern and resets to defaults
-func (c *CurveTimeController) Quit() {
-	c.sclangAdapter.Send("/pattern/curve_time/reset")
-	c.isPlaying = false
+	return strings.Join(lines, "\n")
 }
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"forbidden_sequencer/adapter"
+	"forbidden_sequencer/rhythm"
+)
+
+// EuclidController controls the euclid pattern in sclang via OSC
+// Each voice spreads its pulses over its own number of steps, so voices
+// with different step counts drift against each other (polymeter)
+type EuclidController struct {
+	*ParamController
+}
+
+// euclidDefaults are the starting pulses, steps and rotation of each voice
+var euclidDefaults = map[string][3]float64{
+	"bd":    {4, 16, 0},
+	"cp":    {2, 16, 4},
+	"hh":    {7, 16, 0},
+	"fm2op": {5, 12, 0},
+}
+
+// euclidMix are the default amp and reverb send of each voice
+var euclidMix = map[string][2]float64{
+	"bd":    {0.8, 0},
+	"cp":    {0.7, 1},
+	"hh":    {0.6, 0},
+	"fm2op": {0.4, 1},
+}
+
+// pulseBounds limits a voice's pulses to its step count
+func pulseBounds(voice string) func(values map[string]float64) (float64, float64) {
+	return func(values map[string]float64) (float64, float64) {
+		return 0, values[voice+"/steps"]
+	}
+}
+
+// rotationBounds limits a voice's rotation to within its step count
+func rotationBounds(voice string) func(values map[string]float64) (float64, float64) {
+	return func(values map[string]float64) (float64, float64) {
+		return 0, values[voice+"/steps"] - 1
+	}
+}
+
+// euclidSchema declares the euclid parameters (defaults match euclid.scd)
+func euclidSchema() Schema {
+	voices := []Voice{
+		{Name: "bd", Label: "Kick"},
+		{Name: "cp", Label: "Clap"},
+		{Name: "hh", Label: "Hihat"},
+		{Name: "fm2op", Label: "FM"},
+	}
+
+	params := []*Param{
+		{Name: "base_event_dur", Label: "Base", Help: "base event dur", Type: ParamFloat, Min: 0.025, Max: 1.0, Step: 0.005, Default: 0.125, Format: FormatFloat(3, "s")},
+	}
+
+	for _, voice := range voices {
+		defaults := euclidDefaults[voice.Name]
+		params = append(params,
+			&Param{Name: voice.Name + "/pulses", Label: "pulses", Help: "pulses", Type: ParamInt, Step: 1, Default: defaults[0], Voice: voice.Name, DecKey: "e", IncKey: "E", Bounds: pulseBounds(voice.Name)},
+			&Param{Name: voice.Name + "/steps", Label: "steps", Help: "steps", Type: ParamInt, Min: 1, Max: 32, Step: 1, Default: defaults[1], Voice: voice.Name, DecKey: "s", IncKey: "S"},
+			&Param{Name: voice.Name + "/rotation", Label: "rotation", Help: "rotation", Type: ParamInt, Step: 1, Default: defaults[2], Voice: voice.Name, DecKey: "o", IncKey: "O", Bounds: rotationBounds(voice.Name)},
+		)
+		params = append(params, MixParams(voice.Name, euclidMix[voice.Name][0], euclidMix[voice.Name][1])...)
+	}
+
+	params = append(params,
+		&Param{Name: "fm2op/note", Label: "note", Help: "FM note", Type: ParamInt, Min: 24, Max: 96, Step: 1, Default: 60, Voice: "fm2op", DecKey: "n", IncKey: "N", Format: formatNote},
+		&Param{Name: "debug", Label: "debug", Help: "debug", Type: ParamBool, DecKey: "x", Transient: true},
+	)
+
+	return Schema{
+		Name:         "Euclid",
+		Namespace:    "/pattern/euclid",
+		Voices:       voices,
+		Params:       params,
+		TempoParam:   "base_event_dur",
+		PhraseEvents: longestCycle(voices),
+	}
+}
+
+// longestCycle returns the steps of the longest voice, which is the phrase
+// length: every voice has played at least one full cycle by then
+func longestCycle(voices []Voice) func(values map[string]float64) float64 {
+	return func(values map[string]float64) float64 {
+		steps := 0.0
+		for _, voice := range voices {
+			steps = max(steps, values[voice.Name+"/steps"])
+		}
+		return steps
+	}
+}
+
+// NewEuclidController creates a new euclidean rhythm controller
+func NewEuclidController(sclangAdapter *adapter.OSCAdapter) *EuclidController {
+	return &EuclidController{
+		ParamController: NewParamController(euclidSchema(), sclangAdapter),
+	}
+}
+
+// Pattern returns a voice's step pattern with rotation applied
+func (c *EuclidController) Pattern(voice string) []bool {
+	pattern := rhythm.Bjorklund(int(c.Value(voice+"/pulses")), int(c.Value(voice+"/steps")))
+	return rhythm.Rotate(pattern, int(c.Value(voice+"/rotation")))
+}
+
+// Timeline draws each voice's step grid
+// Steps are two columns wide when they fit, otherwise one
+func (c *EuclidController) Timeline(width int) string {
+	const labelWidth = 8
+	active := c.ActiveVoice().Name
+
+	var lines []string
+	for _, voice := range c.Voices() {
+		pattern := c.Pattern(voice.Name)
+		cell := "%s "
+		if 2*len(pattern) > width-labelWidth-7 {
+			cell = "%s"
+		}
+
+		var row strings.Builder
+		pulses := 0
+		for _, on := range pattern {
+			mark := "·"
+			if on {
+				mark = "●"
+				pulses++
+			}
+			row.WriteString(fmt.Sprintf(cell, mark))
+		}
+
+		label := voice.Label
+		if voice.Name == active {
+			label = "> " + label
+		}
+		count := fmt.Sprintf("%d/%d", pulses, len(pattern))
+		lines = append(lines, fmt.Sprintf("%-*s%5s  %s", labelWidth, label, count, row.String()))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import "testing"
+
+func TestEuclidStepsReclampPulsesAndRotation(t *testing.T) {
+	sclangAdapter, _ := fakeSClang(t)
+	c := NewEuclidController(sclangAdapter)
+	c.SetValue("hh/rotation", 10)
+
+	c.setRecorded("hh/steps", 5)
+	if got := c.Value("hh/pulses"); got != 5 {
+		t.Errorf("pulses after steps 16 -> 5 = %g, want 5", got)
+	}
+	if got := c.Value("hh/rotation"); got != 4 {
+		t.Errorf("rotation after steps 16 -> 5 = %g, want 4", got)
+	}
+
+	// Undo restores the dependent values along with the steps
+	c.Undo()
+	if c.Value("hh/steps") != 16 || c.Value("hh/pulses") != 7 || c.Value("hh/rotation") != 10 {
+		t.Errorf("after undo steps/pulses/rotation = %g/%g/%g, want 16/7/10",
+			c.Value("hh/steps"), c.Value("hh/pulses"), c.Value("hh/rotation"))
+	}
+	c.Redo()
+	if c.Value("hh/pulses") != 5 || c.Value("hh/rotation") != 4 {
+		t.Errorf("after redo pulses/rotation = %g/%g, want 5/4", c.Value("hh/pulses"), c.Value("hh/rotation"))
+	}
+}
+
+func TestEuclidRestoreClampsAgainstRestoredSteps(t *testing.T) {
+	sclangAdapter, _ := fakeSClang(t)
+	c := NewEuclidController(sclangAdapter)
+	c.SetValue("bd/steps", 4)
+
+	// Pulses come before steps in the schema but must see the new steps
+	c.Restore(map[string]float64{"bd/pulses": 9, "bd/steps": 12})
+	if got := c.Value("bd/pulses"); got != 9 {
+		t.Errorf("restored pulses = %g, want 9", got)
+	}
+}
+
+func TestEuclidReportedStepsReclamp(t *testing.T) {
+	sclangAdapter, _ := fakeSClang(t)
+	c := NewEuclidController(sclangAdapter)
+
+	c.applyState(map[string]float64{"cp/steps": 3})
+	if got := c.Value("cp/pulses"); got != 2 {
+		t.Errorf("pulses = %g, want 2 (within 3 steps)", got)
+	}
+	if got := c.Value("cp/rotation"); got != 2 {
+		t.Errorf("rotation = %g, want 2 (clamped from 4)", got)
+	}
+}
+
+func TestEuclidPhraseDuration(t *testing.T) {
+	sclangAdapter, _ := fakeSClang(t)
+	c := NewEuclidController(sclangAdapter)
+
+	// 16 steps (the longest voice) of 0.125s
+	if got := c.PhraseDuration(); got != 2 {
+		t.Errorf("PhraseDuration = %g, want 2", got)
+	}
+	c.SetValue("fm2op/steps", 24)
+	if got := c.PhraseDuration(); got != 3 {
+		t.Errorf("PhraseDuration with a 24-step voice = %g, want 3", got)
+	}
+}
@@ -0,0 +1,183 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"forbidden_sequencer/adapter"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// fxParams returns the fdnReverb controls, with defaults matching setup.scd
+func fxParams() []*Param {
+	return []*Param{
+		{Name: "size", Label: "Size", Help: "size", Type: ParamFloat, Min: 0.1, Max: 1, Step: 0.05, Default: 0.7, DecKey: "s", IncKey: "S"},
+		{Name: "feedback", Label: "Feedback", Help: "feedback", Type: ParamFloat, Min: 0, Max: 0.99, Step: 0.01, Default: 0.9, DecKey: "f", IncKey: "F"},
+		{Name: "hpass", Label: "High-pass", Help: "high-pass", Type: ParamFloat, Min: 20, Max: 2000, Step: 20, Default: 200, DecKey: "h", IncKey: "H", Format: FormatFloat(0, " Hz")},
+		{Name: "lpass", Label: "Low-pass", Help: "low-pass", Type: ParamFloat, Min: 1000, Max: 20000, Step: 500, Default: 16000, DecKey: "l", IncKey: "L", Format: FormatFloat(0, " Hz")},
+		{Name: "wet", Label: "Wet", Help: "wet", Type: ParamFloat, Min: 0, Max: 1, Step: 0.05, Default: 0.5, DecKey: "w", IncKey: "W", Format: FormatPercent},
+		{Name: "earlyMix", Label: "Early", Help: "early reflections", Type: ParamFloat, Min: 0, Max: 1, Step: 0.05, Default: 0.3, DecKey: "e", IncKey: "E", Format: FormatPercent},
+	}
+}
+
+// FXController drives the global reverb by setting its node directly on scsynth
+// It isn't a pattern: it runs alongside whichever pattern controller is active
+type FXController struct {
+	scsynthAdapter *adapter.ScsynthAdapter
+	params         []*Param
+	values         map[string]float64
+}
+
+// NewFXController creates a controller for the reverb node
+// scsynthAdapter must target the server (port 57110), not sclang
+func NewFXController(scsynthAdapter *adapter.ScsynthAdapter) *FXController {
+	c := &FXController{
+		scsynthAdapter: scsynthAdapter,
+		params:         fxParams(),
+		values:         make(map[string]float64),
+	}
+	for _, p := range c.params {
+		c.values[p.Name] = p.Default
+	}
+	return c
+}
+
+// GetName returns the display name
+func (c *FXController) GetName() string {
+	return "FX: Reverb"
+}
+
+// ID returns a stable identifier for the effects
+func (c *FXController) ID() string {
+	return "fx"
+}
+
+// Params returns the parameter descriptors
+func (c *FXController) Params() []*Param {
+	return c.params
+}
+
+// Param returns the descriptor for a parameter name, or nil
+func (c *FXController) Param(name string) *Param {
+	for _, p := range c.params {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Value returns the current value of a parameter
+func (c *FXController) Value(name string) float64 {
+	return c.values[name]
+}
+
+// SetValue clamps and stores a parameter value and sets it on the reverb node
+// Returns true if the stored value changed
+func (c *FXController) SetValue(name string, v float64) bool {
+	p := c.Param(name)
+	if p == nil {
+		return false
+	}
+
+	v = p.Clamp(v, c.values)
+	if v == c.values[name] {
+		return false
+	}
+
+	c.values[name] = v
+	c.scsynthAdapter.NSetLatest(adapter.ReverbNodeID, p.Name, p.Arg(v))
+	return true
+}
+
+// GetKeybindings returns the effect controls
+func (c *FXController) GetKeybindings() string {
+	lines := []string{"ctrl+p: push all to scsynth"}
+	for _, p := range c.params {
+		lines = append(lines, fmt.Sprintf("%s/%s: %s", p.DecKey, p.IncKey, p.Help))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GetStatus returns the current reverb settings
+func (c *FXController) GetStatus() string {
+	var parts []string
+	for _, p := range c.params {
+		parts = append(parts, fmt.Sprintf("%s: %s", p.Label, p.FormatValue(c.values[p.Name])))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Summary returns the main reverb settings on one line for the pattern view
+// (e.g. "wet 50%, size 0.70, feedback 0.90")
+func (c *FXController) Summary() string {
+	var parts []string
+	for _, name := range []string{"wet", "size", "feedback"} {
+		p := c.Param(name)
+		parts = append(parts, fmt.Sprintf("%s %s", strings.ToLower(p.Label), p.FormatValue(c.values[name])))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// HandleInput processes effect keys
+func (c *FXController) HandleInput(msg tea.KeyMsg) bool {
+	key := msg.String()
+	if key == "ctrl+p" {
+		c.PushAll()
+		return true
+	}
+
+	for _, p := range c.params {
+		switch key {
+		case p.DecKey:
+			c.SetValue(p.Name, p.Stepped(c.values[p.Name], -1))
+			return true
+		case p.IncKey:
+			c.SetValue(p.Name, p.Stepped(c.values[p.Name], 1))
+			return true
+		}
+	}
+	return false
+}
+
+// Query asks scsynth for the reverb's current controls
+// The server answers with /n_set, handled by HandleOSC
+func (c *FXController) Query() {
+	names := make([]string, len(c.params))
+	for i, p := range c.params {
+		names[i] = p.Name
+	}
+	c.scsynthAdapter.SGet(adapter.ReverbNodeID, names...)
+}
+
+// PushAll re-sends every control, e.g. after the reverb was recreated
+// Controls are sent one at a time so a failed one is retried on its own
+func (c *FXController) PushAll() {
+	for _, p := range c.params {
+		c.scsynthAdapter.NSetLatest(adapter.ReverbNodeID, p.Name, p.Arg(c.values[p.Name]))
+	}
+}
+
+// HandleOSC adopts the values scsynth reports for the reverb node
+// (the reply to /s_get is an /n_set of node ID then name/value pairs)
+func (c *FXController) HandleOSC(msg *osc.Message) bool {
+	if msg.Address != "/n_set" || len(msg.Arguments) < 1 {
+		return false
+	}
+	if node, ok := numericArg(msg.Arguments[0]); !ok || node != adapter.ReverbNodeID {
+		return false
+	}
+
+	for name, v := range parseState(&osc.Message{Arguments: msg.Arguments[1:]}) {
+		if p := c.Param(name); p != nil {
+			c.values[name] = p.Clamp(v, c.values)
+		}
+	}
+	return true
+}
+
+// Quit leaves the reverb as it is; it belongs to setup.scd rather than a
+// pattern, and its settings are read back with Query on the next start
+func (c *FXController) Quit() {}
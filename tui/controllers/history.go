@@ -0,0 +1,54 @@
+package controllers
+
+// maxHistory is the number of undoable commands kept per controller
+const maxHistory = 100
+
+// paramChange is a single parameter moving from one value to another
+type paramChange struct {
+	name     string
+	old, new float64
+}
+
+// command is an invertible group of parameter changes
+// A keypress produces one change; a preset recall produces one per parameter
+type command []paramChange
+
+// history holds a controller's undo and redo stacks
+type history struct {
+	undo []command
+	redo []command
+}
+
+// record pushes a command and clears the redo stack
+func (h *history) record(cmd command) {
+	if len(cmd) == 0 {
+		return
+	}
+	h.undo = append(h.undo, cmd)
+	if len(h.undo) > maxHistory {
+		h.undo = h.undo[len(h.undo)-maxHistory:]
+	}
+	h.redo = nil
+}
+
+// popUndo removes the most recent command and moves it to the redo stack
+func (h *history) popUndo() (command, bool) {
+	if len(h.undo) == 0 {
+		return nil, false
+	}
+	cmd := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, cmd)
+	return cmd, true
+}
+
+// popRedo removes the most recently undone command and moves it back to undo
+func (h *history) popRedo() (command, bool) {
+	if len(h.redo) == 0 {
+		return nil, false
+	}
+	cmd := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, cmd)
+	return cmd, true
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import "testing"
+
+// change returns a command moving one parameter from 0 to 1
+func change(name string) command {
+	return command{{name: name, old: 0, new: 1}}
+}
+
+func TestHistory(t *testing.T) {
+	type step struct {
+		op   string // "record <name>", "undo" or "redo"
+		want string // command popped ("" if none)
+	}
+	tests := []struct {
+		name       string
+		steps      []step
+		undo, redo int // stack sizes at the end
+	}{
+		{"undo with nothing recorded", []step{{"undo", ""}}, 0, 0},
+		{"redo with nothing undone", []step{{"record a", ""}, {"redo", ""}}, 1, 0},
+		{"empty command not recorded", []step{{"record ", ""}, {"undo", ""}}, 0, 0},
+		{"undo in reverse order", []step{{"record a", ""}, {"record b", ""}, {"undo", "b"}, {"undo", "a"}, {"undo", ""}}, 0, 2},
+		{"redo in undo order", []step{{"record a", ""}, {"record b", ""}, {"undo", "b"}, {"undo", "a"}, {"redo", "a"}, {"redo", "b"}, {"redo", ""}}, 2, 0},
+		{"new edit clears redo", []step{{"record a", ""}, {"record b", ""}, {"undo", "b"}, {"record c", ""}, {"redo", ""}, {"undo", "c"}, {"undo", "a"}}, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h history
+			for i, s := range tt.steps {
+				var cmd command
+				var ok bool
+				switch s.op {
+				case "undo":
+					cmd, ok = h.popUndo()
+				case "redo":
+					cmd, ok = h.popRedo()
+				default:
+					if name := s.op[len("record "):]; name != "" {
+						h.record(change(name))
+					} else {
+						h.record(nil)
+					}
+					continue
+				}
+
+				if ok != (s.want != "") {
+					t.Fatalf("step %d %s: ok = %v, want %v", i+1, s.op, ok, s.want != "")
+				}
+				if ok && cmd[0].name != s.want {
+					t.Fatalf("step %d %s: popped %q, want %q", i+1, s.op, cmd[0].name, s.want)
+				}
+			}
+			if len(h.undo) != tt.undo || len(h.redo) != tt.redo {
+				t.Errorf("stacks = %d undo, %d redo, want %d, %d", len(h.undo), len(h.redo), tt.undo, tt.redo)
+			}
+		})
+	}
+}
+
+func TestHistoryKeepsLatestCommands(t *testing.T) {
+	var h history
+	for i := 0; i < maxHistory+10; i++ {
+		h.record(command{{name: "a", new: float64(i)}})
+	}
+	if len(h.undo) != maxHistory {
+		t.Fatalf("len(undo) = %d, want %d", len(h.undo), maxHistory)
+	}
+	if got := h.undo[0][0].new; got != 10 {
+		t.Errorf("oldest kept command = %g, want 10 (the first ones dropped)", got)
+	}
+}
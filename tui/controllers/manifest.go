@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"forbidden_sequencer/adapter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest describes a pattern so it can be controlled without Go code
+// Manifests live next to the .scd files as <pattern>.json, .yaml or .yml
+type Manifest struct {
+	Name        string          `json:"name" yaml:"name"`                 // display name
+	Namespace   string          `json:"namespace" yaml:"namespace"`       // e.g. "/pattern/my_pattern"
+	PhraseParam string          `json:"phrase_param" yaml:"phrase_param"` // optional events-per-phrase parameter
+	TempoParam  string          `json:"tempo_param" yaml:"tempo_param"`   // optional event duration parameter, driven by the global tempo
+	Voices      []Voice         `json:"voices" yaml:"voices"`
+	Params      []ManifestParam `json:"params" yaml:"params"`
+	Transport   Transport       `json:"transport" yaml:"transport"`
+}
+
+// ManifestParam is the serialised form of a Param
+type ManifestParam struct {
+	Name      string   `json:"name" yaml:"name"`           // address suffix, e.g. "kick/prob"
+	Label     string   `json:"label" yaml:"label"`         // status label (defaults to name)
+	Help      string   `json:"help" yaml:"help"`           // keybinding description (defaults to label)
+	Type      string   `json:"type" yaml:"type"`           // "float" (default), "int" or "bool"
+	Min       float64  `json:"min" yaml:"min"`             // lower bound
+	Max       float64  `json:"max" yaml:"max"`             // upper bound
+	Step      float64  `json:"step" yaml:"step"`           // per-keypress change
+	Default   float64  `json:"default" yaml:"default"`     // initial value, should match the .scd
+	Voice     string   `json:"voice" yaml:"voice"`         // owning voice ("" for pattern-wide)
+	Keys      []string `json:"keys" yaml:"keys"`           // [decrease, increase] or [toggle] for bools
+	Format    string   `json:"format" yaml:"format"`       // "percent", "signed", "pan" or a printf verb like "%.3fs"
+	Transient bool     `json:"transient" yaml:"transient"` // excluded from presets (e.g. debug)
+}
+
+// manifestExtensions are the file extensions recognised as manifests
+var manifestExtensions = map[string]bool{".json": true, ".yaml": true, ".yml": true}
+
+// reservedKeys are taken by every ParamController (transport, undo, mute,
+// voice select) or by the main screen (tempo, solo, screens), along with
+// every ctrl combination; a manifest can't bind them
+var reservedKeys = map[string]bool{
+	"p": true, " ": true, "space": true, "u": true, "U": true, "m": true, "M": true,
+	"1": true, "2": true, "3": true, "4": true, "5": true, "6": true, "7": true, "8": true, "9": true,
+	"t": true, "T": true, "-": true, "=": true, "<": true, ">": true,
+	"X": true, "L": true, "P": true, "q": true, "esc": true, "tab": true,
+	"pgup": true, "pgdown": true, "end": true,
+}
+
+// LoadManifests builds controllers from every manifest in dir
+// A missing directory is not an error. Invalid manifests are skipped and
+// reported in the returned error while valid ones are still loaded
+func LoadManifests(dir string, sclangAdapter *adapter.OSCAdapter) ([]Controller, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read patterns directory: %w", err)
+	}
+
+	var loaded []Controller
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !manifestExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		manifest, err := ReadManifest(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		schema, err := manifest.Schema()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name(), err))
+			continue
+		}
+
+		loaded = append(loaded, NewParamController(schema, sclangAdapter))
+	}
+
+	return loaded, errors.Join(errs...)
+}
+
+// ReadManifest parses a JSON or YAML manifest file
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, &manifest)
+	} else {
+		err = yaml.Unmarshal(data, &manifest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", filepath.Base(path), err)
+	}
+
+	return &manifest, nil
+}
+
+// Schema validates the manifest and converts it to a controller Schema
+func (m *Manifest) Schema() (Schema, error) {
+	if m.Name == "" {
+		return Schema{}, errors.New("manifest has no name")
+	}
+	if !strings.HasPrefix(m.Namespace, "/") {
+		return Schema{}, fmt.Errorf("namespace %q must start with /", m.Namespace)
+	}
+
+	voices := make(map[string]bool, len(m.Voices))
+	for i, v := range m.Voices {
+		if v.Name == "" {
+			return Schema{}, fmt.Errorf("voice %d has no name", i+1)
+		}
+		if v.Label == "" {
+			m.Voices[i].Label = v.Name
+		}
+		voices[v.Name] = true
+	}
+
+	schema := Schema{
+		Name:        m.Name,
+		Namespace:   strings.TrimSuffix(m.Namespace, "/"),
+		Voices:      m.Voices,
+		PhraseParam: m.PhraseParam,
+		TempoParam:  m.TempoParam,
+		Transport:   m.Transport,
+	}
+
+	names := make(map[string]bool, len(m.Params))
+	for _, mp := range m.Params {
+		p, err := mp.param()
+		if err != nil {
+			return Schema{}, err
+		}
+		if names[p.Name] {
+			return Schema{}, fmt.Errorf("param %q is declared twice", p.Name)
+		}
+		if p.Voice != "" && !voices[p.Voice] {
+			return Schema{}, fmt.Errorf("param %q refers to unknown voice %q", p.Name, p.Voice)
+		}
+		names[p.Name] = true
+		schema.Params = append(schema.Params, p)
+	}
+	if err := checkKeys(schema.Params); err != nil {
+		return Schema{}, err
+	}
+
+	if m.PhraseParam != "" && !names[m.PhraseParam] {
+		return Schema{}, fmt.Errorf("phrase_param %q is not a declared param", m.PhraseParam)
+	}
+	if m.TempoParam != "" && !names[m.TempoParam] {
+		return Schema{}, fmt.Errorf("tempo_param %q is not a declared param", m.TempoParam)
+	}
+
+	return schema, nil
+}
+
+// checkKeys rejects reserved keys and keys bound twice
+// Params of different voices may share a key (only the active voice's
+// param reacts), but a pattern-wide key must be unique
+func checkKeys(params []*Param) error {
+	byKey := make(map[string][]*Param)
+	for _, p := range params {
+		for _, key := range []string{p.DecKey, p.IncKey} {
+			if key == "" {
+				continue
+			}
+			if reservedKeys[key] || strings.HasPrefix(key, "ctrl+") {
+				return fmt.Errorf("param %q binds key %q, which the TUI reserves", p.Name, key)
+			}
+			for _, other := range byKey[key] {
+				if other != p && (other.Voice == p.Voice || other.Voice == "" || p.Voice == "") {
+					return fmt.Errorf("key %q is bound by both %q and %q", key, other.Name, p.Name)
+				}
+			}
+			byKey[key] = append(byKey[key], p)
+		}
+	}
+	return nil
+}
+
+// param converts a manifest parameter to a Param
+func (mp ManifestParam) param() (*Param, error) {
+	if mp.Name == "" {
+		return nil, errors.New("param has no name")
+	}
+
+	p := &Param{
+		Name:      strings.Trim(mp.Name, "/"),
+		Label:     mp.Label,
+		Help:      mp.Help,
+		Min:       mp.Min,
+		Max:       mp.Max,
+		Step:      mp.Step,
+		Default:   mp.Default,
+		Voice:     mp.Voice,
+		Transient: mp.Transient,
+	}
+	if p.Label == "" {
+		p.Label = p.Name
+	}
+	if p.Help == "" {
+		p.Help = p.Label
+	}
+
+	switch mp.Type {
+	case "", "float":
+		p.Type = ParamFloat
+	case "int":
+		p.Type = ParamInt
+	case "bool":
+		p.Type = ParamBool
+	default:
+		return nil, fmt.Errorf("param %q has unknown type %q", mp.Name, mp.Type)
+	}
+
+	if p.Type != ParamBool {
+		if p.Min > p.Max {
+			return nil, fmt.Errorf("param %q has min greater than max", mp.Name)
+		}
+		if p.Step <= 0 {
+			return nil, fmt.Errorf("param %q needs a positive step", mp.Name)
+		}
+	}
+
+	switch len(mp.Keys) {
+	case 0:
+		// Not bound to a key (still sent on push and shown in status)
+	case 1:
+		p.DecKey = mp.Keys[0]
+	case 2:
+		p.DecKey, p.IncKey = mp.Keys[0], mp.Keys[1]
+	default:
+		return nil, fmt.Errorf("param %q has more than two keys", mp.Name)
+	}
+
+	switch mp.Format {
+	case "":
+	case "percent":
+		p.Format = FormatPercent
+	case "signed":
+		p.Format = FormatSigned
+	case "pan":
+		p.Format = FormatPan
+	default:
+		format, isInt := mp.Format, p.Type != ParamFloat
+		p.Format = func(v float64) string {
+			if isInt {
+				return fmt.Sprintf(format, int(v))
+			}
+			return fmt.Sprintf(format, v)
+		}
+	}
+
+	return p, nil
+}
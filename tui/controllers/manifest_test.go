@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const yamlManifest = `name: Drone
+namespace: /pattern/drone/
+phrase_param: length
+voices:
+  - name: pad
+params:
+  - name: length
+    type: int
+    min: 4
+    max: 32
+    step: 1
+    default: 16
+  - name: pad/amp
+    voice: pad
+    max: 1
+    step: 0.05
+    default: 0.5
+    keys: [a, A]
+    format: percent
+  - name: debug
+    type: bool
+    keys: [x]
+    transient: true
+`
+
+const jsonManifest = `{
+  "name": "Pulse",
+  "namespace": "/pattern/pulse",
+  "tempo_param": "dur",
+  "params": [
+    {"name": "dur", "min": 0.05, "max": 1, "step": 0.05, "default": 0.25, "format": "%.2fs"}
+  ]
+}`
+
+// writeManifests creates a patterns directory holding the given files
+func writeManifests(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLoadManifests(t *testing.T) {
+	dir := writeManifests(t, map[string]string{
+		"drone.yaml": yamlManifest,
+		"pulse.JSON": jsonManifest,
+		"drone.scd":  "// not a manifest",
+		"broken.yml": "name: [unclosed",
+		"notes.txt":  "{}",
+	})
+
+	loaded, err := LoadManifests(dir, nil)
+	if err == nil || !strings.Contains(err.Error(), "broken.yml") {
+		t.Errorf("err = %v, want a parse error for broken.yml", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("loaded %d controllers, want 2", len(loaded))
+	}
+
+	names := map[string]bool{}
+	for _, c := range loaded {
+		names[c.GetName()] = true
+	}
+	if !names["Drone"] || !names["Pulse"] {
+		t.Errorf("loaded %v, want Drone and Pulse", names)
+	}
+}
+
+func TestLoadManifestsMissingDirectory(t *testing.T) {
+	loaded, err := LoadManifests(filepath.Join(t.TempDir(), "nope"), nil)
+	if err != nil || loaded != nil {
+		t.Errorf("missing directory gave %v, %v; want nothing and no error", loaded, err)
+	}
+}
+
+func TestManifestSchema(t *testing.T) {
+	dir := writeManifests(t, map[string]string{"drone.yaml": yamlManifest})
+	manifest, err := ReadManifest(filepath.Join(dir, "drone.yaml"))
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+	schema, err := manifest.Schema()
+	if err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+
+	if schema.Namespace != "/pattern/drone" {
+		t.Errorf("namespace = %q, want the trailing slash trimmed", schema.Namespace)
+	}
+	if schema.Voices[0].Label != "pad" {
+		t.Errorf("voice label = %q, want it to default to the name", schema.Voices[0].Label)
+	}
+	if len(schema.Params) != 3 {
+		t.Fatalf("%d params, want 3", len(schema.Params))
+	}
+
+	length, amp, debug := schema.Params[0], schema.Params[1], schema.Params[2]
+	if length.Type != ParamInt || length.Label != "length" || length.Help != "length" {
+		t.Errorf("length = %+v", length)
+	}
+	if amp.Type != ParamFloat || amp.DecKey != "a" || amp.IncKey != "A" || amp.FormatValue(0.5) != "50%" {
+		t.Errorf("pad/amp = %+v", amp)
+	}
+	if debug.Type != ParamBool || debug.DecKey != "x" || !debug.Transient {
+		t.Errorf("debug = %+v", debug)
+	}
+}
+
+func TestManifestVoicesShareKeys(t *testing.T) {
+	manifest := Manifest{Name: "X", Namespace: "/pattern/x", Voices: []Voice{{Name: "kick"}, {Name: "hat"}}, Params: []ManifestParam{
+		{Name: "kick/amp", Voice: "kick", Max: 1, Step: 0.1, Keys: []string{"a", "A"}},
+		{Name: "hat/amp", Voice: "hat", Max: 1, Step: 0.1, Keys: []string{"a", "A"}},
+	}}
+	if _, err := manifest.Schema(); err != nil {
+		t.Errorf("voices sharing a key were rejected: %v", err)
+	}
+}
+
+func TestManifestSchemaErrors(t *testing.T) {
+	param := ManifestParam{Name: "amp", Max: 1, Step: 0.1}
+
+	tests := []struct {
+		name     string
+		manifest Manifest
+		wantErr  string
+	}{
+		{"no name", Manifest{Namespace: "/pattern/x"}, "no name"},
+		{"relative namespace", Manifest{Name: "X", Namespace: "pattern/x"}, "must start with /"},
+		{"nameless voice", Manifest{Name: "X", Namespace: "/pattern/x", Voices: []Voice{{}}}, "voice 1 has no name"},
+		{"duplicate param", Manifest{Name: "X", Namespace: "/pattern/x", Params: []ManifestParam{param, param}}, "declared twice"},
+		{"unknown voice", Manifest{Name: "X", Namespace: "/pattern/x", Params: []ManifestParam{{Name: "kick/amp", Voice: "kick", Max: 1, Step: 0.1}}}, "unknown voice"},
+		{"unknown type", Manifest{Name: "X", Namespace: "/pattern/x", Params: []ManifestParam{{Name: "amp", Type: "string"}}}, "unknown type"},
+		{"min above max", Manifest{Name: "X", Namespace: "/pattern/x", Params: []ManifestParam{{Name: "amp", Min: 2, Max: 1, Step: 0.1}}}, "min greater than max"},
+		{"no step", Manifest{Name: "X", Namespace: "/pattern/x", Params: []ManifestParam{{Name: "amp", Max: 1}}}, "positive step"},
+		{"too many keys", Manifest{Name: "X", Namespace: "/pattern/x", Params: []ManifestParam{{Name: "amp", Max: 1, Step: 0.1, Keys: []string{"a", "b", "c"}}}}, "more than two keys"},
+		{"reserved key", Manifest{Name: "X", Namespace: "/pattern/x", Params: []ManifestParam{{Name: "amp", Max: 1, Step: 0.1, Keys: []string{"a", "m"}}}}, "reserves"},
+		{"reserved digit", Manifest{Name: "X", Namespace: "/pattern/x", Params: []ManifestParam{{Name: "on", Type: "bool", Keys: []string{"3"}}}}, "reserves"},
+		{"reserved ctrl key", Manifest{Name: "X", Namespace: "/pattern/x", Params: []ManifestParam{{Name: "on", Type: "bool", Keys: []string{"ctrl+k"}}}}, "reserves"},
+		{"duplicate pattern-wide key", Manifest{Name: "X", Namespace: "/pattern/x", Params: []ManifestParam{
+			{Name: "amp", Max: 1, Step: 0.1, Keys: []string{"a", "A"}},
+			{Name: "on", Type: "bool", Keys: []string{"a"}},
+		}}, "bound by both"},
+		{"voice key shadowed by a pattern-wide key", Manifest{Name: "X", Namespace: "/pattern/x", Voices: []Voice{{Name: "kick"}}, Params: []ManifestParam{
+			{Name: "kick/amp", Voice: "kick", Max: 1, Step: 0.1, Keys: []string{"a", "A"}},
+			{Name: "on", Type: "bool", Keys: []string{"A"}},
+		}}, "bound by both"},
+		{"duplicate key within a voice", Manifest{Name: "X", Namespace: "/pattern/x", Voices: []Voice{{Name: "kick"}}, Params: []ManifestParam{
+			{Name: "kick/amp", Voice: "kick", Max: 1, Step: 0.1, Keys: []string{"a", "A"}},
+			{Name: "kick/pan", Voice: "kick", Min: -1, Max: 1, Step: 0.1, Keys: []string{"a", "A"}},
+		}}, "bound by both"},
+		{"undeclared phrase param", Manifest{Name: "X", Namespace: "/pattern/x", PhraseParam: "length"}, "phrase_param"},
+		{"undeclared tempo param", Manifest{Name: "X", Namespace: "/pattern/x", TempoParam: "dur"}, "tempo_param"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.manifest.Schema()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,50 +5,113 @@ import (
 	"strings"
 
 	"forbidden_sequencer/adapter"
+	"forbidden_sequencer/theory"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hypebeast/go-osc/osc"
 )
 
 // MarkovChordController controls the markov_chord pattern in sclang via OSC
 type MarkovChordController struct {
-	sclangAdapter     *adapter.OSCAdapter
-	baseEventDur      float64
-	phraseLength      int
-	phrasesPerSection int
-	rootNote          int
-	debug             bool
-	isPlaying         bool
-	currentSection    string // "Chord" or "Percussion" (for display)
+	*ParamController
+	currentSection string // "Chord" or "Percussion" (for display)
 }
 
-// NewMarkovChordController creates a new markov chord controller
-func NewMarkovChordController(sclangAdapter *adapter.OSCAdapter) *MarkovChordController {
-	return &MarkovChordController{
-		sclangAdapter:     sclangAdapter,
-		baseEventDur:      0.125,
-		phraseLength:      16,
-		phrasesPerSection: 2,
-		rootNote:          53, // F3
-		debug:             false,
-		isPlaying:         false,
-		currentSection:    "Chord",
+// markovChordMix are the default amp and reverb send of each voice
+var markovChordMix = map[string][2]float64{
+	"chord": {0.6, 1},
+	"kick":  {0.8, 0},
+	"snare": {0.7, 1},
+	"hihat": {0.6, 0},
+}
+
+// markovChordSchema declares the markov_chord parameters (defaults match markov_chord.scd)
+func markovChordSchema() Schema {
+	voices := []Voice{
+		{Name: "chord", Label: "Chord"},
+		{Name: "kick", Label: "Kick"},
+		{Name: "snare", Label: "Snare"},
+		{Name: "hihat", Label: "Hihat"},
+	}
+
+	params := []*Param{
+		{Name: "base_event_dur", Label: "Base", Help: "base event dur", Type: ParamFloat, Min: 0.025, Max: 1.0, Step: 0.005, Default: 0.125, Format: FormatFloat(3, "s")},
+		{Name: "phrase_length", Label: "Length", Help: "phrase length", Type: ParamInt, Min: 4, Max: 64, Step: 1, Default: 16, DecKey: "r", IncKey: "R"},
+		{Name: "root_note", Label: "Root", Help: "root note", Type: ParamInt, Min: 0, Max: 127, Step: 1, Default: 53, DecKey: "n", IncKey: "N", Format: formatNote}, // F3
+		{Name: "scale", Label: "Scale", Help: "scale", Type: ParamInt, Min: 0, Max: float64(len(theory.Scales) - 1), Step: 1, Default: float64(theory.ScaleIndex("melodic minor")), DecKey: "k", IncKey: "K", Format: formatScale, Encode: encodeScale},
+		{Name: "mode", Label: "Mode", Help: "mode", Type: ParamInt, Min: 0, Step: 1, Default: 0, DecKey: "o", IncKey: "O", Bounds: modeBounds, Address: "scale", Encode: encodeScale},
+		{Name: "voicing", Label: "Voicing", Help: "chord voicing", Type: ParamInt, Min: 0, Max: float64(len(theory.Voicings) - 1), Step: 1, Default: float64(theory.VoicingIndex("seventh")), DecKey: "v", IncKey: "V", Format: formatVoicing, Address: "chord_degrees", Encode: encodeVoicing},
+		{Name: "phrases_per_section", Label: "Phrases", Help: "phrases per section", Type: ParamInt, Min: 1, Max: 16, Step: 1, Default: 2, DecKey: "s", IncKey: "S"},
+	}
+	params = append(params, FeelParams()...)
+
+	for _, voice := range voices {
+		params = append(params, MixParams(voice.Name, markovChordMix[voice.Name][0], markovChordMix[voice.Name][1])...)
+		params = append(params, NudgeParam(voice.Name))
 	}
+
+	params = append(params, &Param{Name: "debug", Label: "debug", Help: "debug", Type: ParamBool, DecKey: "x", Transient: true})
+
+	return Schema{
+		Name:        "Markov Chord",
+		Namespace:   "/pattern/markov_chord",
+		Voices:      voices,
+		Params:      params,
+		TempoParam:  "base_event_dur",
+		PhraseParam: "phrase_length",
+	}
+}
+
+// chordScale returns the selected scale rotated to the selected mode
+func chordScale(values map[string]float64) theory.Scale {
+	scale := theory.Scales[int(values["scale"])]
+	return scale.Mode(int(values["mode"]))
 }
 
-// GetName returns the display name
-func (c *MarkovChordController) GetName() string {
-	return "Markov Chord"
+// modeBounds limits the mode to the degrees of the selected scale
+func modeBounds(values map[string]float64) (float64, float64) {
+	return 0, float64(len(theory.Scales[int(values["scale"])].Intervals) - 1)
 }
 
-// GetKeybindings returns the controller-specific controls
-func (c *MarkovChordController) GetKeybindings() string {
-	return `p: play/stop
-space: pause/resume
-d/D: base event dur
-r/R: phrase length
-n/N: root note
-s/S: phrases per section
-x: debug`
+// encodeScale sends the intervals of the selected scale and mode as a list
+func encodeScale(values map[string]float64) []interface{} {
+	var args []interface{}
+	for _, interval := range chordScale(values).Intervals {
+		args = append(args, int32(interval))
+	}
+	return args
+}
+
+// encodeVoicing sends the chord degrees of the selected voicing as a list
+func encodeVoicing(values map[string]float64) []interface{} {
+	var args []interface{}
+	for _, degree := range theory.Voicings[int(values["voicing"])].Degrees {
+		args = append(args, int32(degree))
+	}
+	return args
+}
+
+// formatNote renders a MIDI note by name (e.g. "F3")
+func formatNote(v float64) string {
+	return theory.NoteName(int(v))
+}
+
+// formatScale renders a scale index by name
+func formatScale(v float64) string {
+	return theory.Scales[int(v)].Name
+}
+
+// formatVoicing renders a voicing index by name
+func formatVoicing(v float64) string {
+	return theory.Voicings[int(v)].Name
+}
+
+// NewMarkovChordController creates a new markov chord controller
+func NewMarkovChordController(sclangAdapter *adapter.OSCAdapter) *MarkovChordController {
+	return &MarkovChordController{
+		ParamController: NewParamController(markovChordSchema(), sclangAdapter),
+		currentSection:  "Chord",
+	}
 }
 
 // GetStatus returns the current state
@@ -56,124 +119,78 @@ func (c *MarkovChordController) GetStatus() string {
 	var status strings.Builder
 
 	// Pattern state
-	phraseDur := float64(c.phraseLength) * c.baseEventDur
-	status.WriteString(fmt.Sprintf("Base: %.3fs, Length: %d, Phrase: %.2fs\n", c.baseEventDur, c.phraseLength, phraseDur))
-	status.WriteString(fmt.Sprintf("Section: %s (%d phrases)\n", c.currentSection, c.phrasesPerSection))
-	status.WriteString(fmt.Sprintf("Root: %d (MIDI)", c.rootNote))
+	status.WriteString(fmt.Sprintf("Base: %.3fs, Length: %d, Phrase: %.2fs\n", c.Value("base_event_dur"), int(c.Value("phrase_length")), c.PhraseDuration()))
+	status.WriteString(fmt.Sprintf("Section: %s (%d phrases)\n", c.currentSection, int(c.Value("phrases_per_section"))))
+	status.WriteString(fmt.Sprintf("Swing: %s, Humanize: %s (seed %d)\n", FormatPercent(c.Value("swing")), FormatPercent(c.Value("humanize")), int(c.Value("humanize_seed"))))
+	status.WriteString(c.harmony())
+	status.WriteString("\n" + c.synths())
 
-	if c.debug {
+	if c.Value("debug") != 0 {
 		status.WriteString("\nDEBUG")
 	}
 
 	return status.String()
 }
 
-// HandleInput processes controller-specific input
-func (c *MarkovChordController) HandleInput(msg tea.KeyMsg) bool {
-	switch msg.String() {
-	case " ":
-		// Toggle pause/resume
-		if c.isPlaying {
-			c.sclangAdapter.Send("/pattern/markov_chord/pause")
-			c.isPlaying = false
-		} else {
-			c.sclangAdapter.Send("/pattern/markov_chord/resume")
-			c.isPlaying = true
-		}
-		return true
-
-	case "p":
-		// Toggle play/stop (reset position)
-		if c.isPlaying {
-			c.sclangAdapter.Send("/pattern/markov_chord/stop")
-			c.isPlaying = false
-		} else {
-			c.sclangAdapter.Send("/pattern/markov_chord/play")
-			c.isPlaying = true
-			c.currentSection = "Chord" // Reset to chord section on play
-		}
-		return true
+// harmony describes the root, scale, mode and chord with note names
+func (c *MarkovChordController) harmony() string {
+	values := map[string]float64{"scale": c.Value("scale"), "mode": c.Value("mode")}
+	scale := chordScale(values)
+	voicing := theory.Voicings[int(c.Value("voicing"))]
+	root := int(c.Value("root_note"))
 
-	case "d":
-		// Decrease base event duration
-		if c.baseEventDur > 0.025 {
-			c.baseEventDur -= 0.005
-			c.sclangAdapter.Send("/pattern/markov_chord/base_event_dur", float32(c.baseEventDur))
-		}
-		return true
-
-	case "D":
-		// Increase base event duration
-		if c.baseEventDur < 1.0 {
-			c.baseEventDur += 0.005
-			c.sclangAdapter.Send("/pattern/markov_chord/base_event_dur", float32(c.baseEventDur))
-		}
-		return true
+	var notes []string
+	for _, note := range scale.Chord(root, voicing.Degrees) {
+		notes = append(notes, theory.NoteName(note))
+	}
 
-	case "r":
-		// Decrease phrase length
-		if c.phraseLength > 4 {
-			c.phraseLength--
-			c.sclangAdapter.Send("/pattern/markov_chord/phrase_length", int32(c.phraseLength))
-		}
-		return true
+	return fmt.Sprintf("Root: %s, Scale: %s (%s)\nChord: %s - %s",
+		theory.NoteName(root), formatScale(c.Value("scale")), scale.Name, voicing.Name, strings.Join(notes, " "))
+}
 
-	case "R":
-		// Increase phrase length
-		if c.phraseLength < 64 {
-			c.phraseLength++
-			c.sclangAdapter.Send("/pattern/markov_chord/phrase_length", int32(c.phraseLength))
+// synths lists the voices on one line with the active one and mutes marked
+func (c *MarkovChordController) synths() string {
+	active := c.ActiveVoice().Name
+	var parts []string
+	for i, voice := range c.Voices() {
+		part := fmt.Sprintf("%d. %s", i+1, voice.Label)
+		if voice.Name == active {
+			part = "> " + part
 		}
-		return true
-
-	case "n":
-		// Decrease root note
-		if c.rootNote > 0 {
-			c.rootNote--
-			c.sclangAdapter.Send("/pattern/markov_chord/root_note", int32(c.rootNote))
+		if mute := c.voiceMuteState(voice.Name); mute != "" {
+			part += " [" + mute + "]"
 		}
-		return true
-
-	case "N":
-		// Increase root note
-		if c.rootNote < 127 {
-			c.rootNote++
-			c.sclangAdapter.Send("/pattern/markov_chord/root_note", int32(c.rootNote))
-		}
-		return true
+		parts = append(parts, part)
+	}
+	return "Synths: " + strings.Join(parts, "  ") + "\nMix: " + c.voiceSummary(active)
+}
 
-	case "s":
-		// Decrease phrases per section
-		if c.phrasesPerSection > 1 {
-			c.phrasesPerSection--
-			c.sclangAdapter.Send("/pattern/markov_chord/phrases_per_section", int32(c.phrasesPerSection))
-		}
-		return true
+// Play starts the pattern from the chord section
+func (c *MarkovChordController) Play() {
+	c.currentSection = "Chord"
+	c.ParamController.Play()
+}
 
-	case "S":
-		// Increase phrases per section
-		if c.phrasesPerSection < 16 {
-			c.phrasesPerSection++
-			c.sclangAdapter.Send("/pattern/markov_chord/phrases_per_section", int32(c.phrasesPerSection))
-		}
-		return true
+// HandleInput processes controller-specific input
+func (c *MarkovChordController) HandleInput(msg tea.KeyMsg) bool {
+	if msg.String() == "p" && !c.IsPlaying() {
+		c.currentSection = "Chord" // Reset to chord section on play
+	}
+	return c.ParamController.HandleInput(msg)
+}
 
-	case "x":
-		// Toggle debug
-		c.debug = !c.debug
-		debugInt := int32(0)
-		if c.debug {
-			debugInt = 1
+// HandleOSC updates local state from messages reported by sclang
+func (c *MarkovChordController) HandleOSC(msg *osc.Message) bool {
+	if msg.Address == "/pattern/markov_chord/section" {
+		// Section switches happen inside the sclang task
+		if section, ok := transportStateArg(msg); ok {
+			if section == "percussion" {
+				c.currentSection = "Percussion"
+			} else {
+				c.currentSection = "Chord"
+			}
 		}
-		c.sclangAdapter.Send("/pattern/markov_chord/debug", debugInt)
 		return true
 	}
-
-	return false
-}
-
-// Quit stops the pattern and resets to defaults
-func (c *MarkovChordController) Quit() {
-	c.sclangAdapter.Send("/pattern/markov_chord/reset")
-	c.isPlaying = false
+	return c.ParamController.HandleOSC(msg)
 }
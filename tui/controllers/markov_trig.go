@@ -1,305 +1,235 @@
 package controllers
 
 import (
-	"fmt"
+	"math"
+	"slices"
 	"strings"
 
 	"forbidden_sequencer/adapter"
 
-	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hypebeast/go-osc/osc"
 )
 
 // MarkovTrigController controls the markov_trig pattern in sclang via OSC
+// Each voice is a Markov chain; its probability param rebuilds a two-state
+// chain, and the full matrix can be edited through TransitionEditor
 type MarkovTrigController struct {
-	sclangAdapter *adapter.OSCAdapter
-	baseEventDur  float64
-	phraseLength  int
-	kickProb      float64
-	snareProb     float64
-	hihatProb     float64
-	fm1Prob       float64
-	fm2Prob       float64
-	debug         bool
-	isPlaying     bool
-	activeSynth   int // 0=kick, 1=snare, 2=hihat, 3=fm1, 4=fm2
+	*ParamController
+	matrices  map[string]*TransitionMatrix // per voice
+	builtFrom map[string]float64           // probability each matrix was last rebuilt from
+}
+
+// markovTrigExtraStates are the optional states markov_trig.scd knows how to play
+var markovTrigExtraStates = []string{"accent", "ghost"}
+
+// markovTrigMix are the default amp and reverb send of each voice
+var markovTrigMix = map[string][2]float64{
+	"kick":  {0.8, 0},
+	"snare": {0.7, 1},
+	"hihat": {0.6, 0},
+	"fm1":   {0.5, 1},
+	"fm2":   {0.4, 1},
+}
+
+// markovTrigSchema declares the markov_trig parameters (defaults match markov_trig.scd)
+func markovTrigSchema() Schema {
+	voices := []Voice{
+		{Name: "kick", Label: "Kick"},
+		{Name: "snare", Label: "Snare"},
+		{Name: "hihat", Label: "Hihat"},
+		{Name: "fm1", Label: "FM1"},
+		{Name: "fm2", Label: "FM2"},
+	}
+	defaultProbs := map[string]float64{"kick": 0.5, "snare": 0.5, "hihat": 0.5, "fm1": 0.3, "fm2": 0.3}
+
+	params := []*Param{
+		{Name: "base_event_dur", Label: "Base", Help: "base event dur", Type: ParamFloat, Min: 0.025, Max: 1.0, Step: 0.005, Default: 0.125, Format: FormatFloat(3, "s")},
+		{Name: "phrase_length", Label: "Length", Help: "phrase length", Type: ParamInt, Min: 4, Max: 64, Step: 1, Default: 16, DecKey: "r", IncKey: "R"},
+	}
+	params = append(params, FeelParams()...)
+
+	for _, voice := range voices {
+		params = append(params, &Param{Name: voice.Name + "/prob", Help: "probability", Type: ParamFloat, Min: 0, Max: 1, Step: 0.1, Default: defaultProbs[voice.Name], Voice: voice.Name, DecKey: "e", IncKey: "E", Format: FormatPercent})
+		params = append(params, MixParams(voice.Name, markovTrigMix[voice.Name][0], markovTrigMix[voice.Name][1])...)
+		params = append(params, NudgeParam(voice.Name))
+	}
+
+	params = append(params, &Param{Name: "debug", Label: "debug", Help: "debug", Type: ParamBool, DecKey: "x", Transient: true})
+
+	return Schema{
+		Name:        "Markov Triggers",
+		Namespace:   "/pattern/markov_trig",
+		Voices:      voices,
+		Params:      params,
+		TempoParam:  "base_event_dur",
+		PhraseParam: "phrase_length",
+	}
 }
 
 // NewMarkovTrigController creates a new markov triggers controller
 func NewMarkovTrigController(sclangAdapter *adapter.OSCAdapter) *MarkovTrigController {
-	return &MarkovTrigController{
-		sclangAdapter: sclangAdapter,
-		baseEventDur:  0.125,
-		phraseLength:  16,
-		kickProb:      0.5,
-		snareProb:     0.5,
-		hihatProb:     0.5,
-		fm1Prob:       0.3,
-		fm2Prob:       0.3,
-		debug:         false,
-		isPlaying:     false,
-		activeSynth:   0, // Start with kick selected
+	c := &MarkovTrigController{
+		ParamController: NewParamController(markovTrigSchema(), sclangAdapter),
+		matrices:        make(map[string]*TransitionMatrix),
+		builtFrom:       make(map[string]float64),
 	}
+	c.pushAll = c.PushAll // a resync restores the matrices too
+	for _, voice := range c.Voices() {
+		c.rebuild(voice.Name)
+	}
+	return c
 }
 
-// GetName returns the display name
-func (c *MarkovTrigController) GetName() string {
-	return "Markov Triggers"
+// BaseStates returns a voice's on and off states
+// The snare decides once per phrase, so its states are named after that
+func (c *MarkovTrigController) BaseStates(voice string) []string {
+	if voice == "snare" {
+		return []string{"trigger", "no_trigger"}
+	}
+	return []string{"playing", "silent"}
 }
 
-// GetKeybindings returns the controller-specific controls
-func (c *MarkovTrigController) GetKeybindings() string {
-	return `p: play/stop
-space: pause/resume
-1-5: select synth
-d/D: base event dur
-r/R: phrase length
-e/E: probability (active synth)
-x: debug`
+// ExtraStates returns the optional states that can be added to a chain
+func (c *MarkovTrigController) ExtraStates() []string {
+	return markovTrigExtraStates
 }
 
-// GetStatus returns the current state
-func (c *MarkovTrigController) GetStatus() string {
-	var status strings.Builder
-
-	// Phrase state
-	phraseDur := float64(c.phraseLength) * c.baseEventDur
-	status.WriteString(fmt.Sprintf("Base: %.3fs, Length: %d, Phrase: %.2fs\n\n", c.baseEventDur, c.phraseLength, phraseDur))
+// Matrix returns a voice's transition matrix
+// sclang rebuilds a two-state chain whenever the voice probability changes,
+// so the local matrix is rebuilt the same way if the probability has moved
+func (c *MarkovTrigController) Matrix(voice string) *TransitionMatrix {
+	if c.Value(voice+"/prob") != c.builtFrom[voice] {
+		c.rebuild(voice)
+	}
+	return c.matrices[voice]
+}
 
-	// Synths section
-	status.WriteString("Synths:\n")
+// rebuild resets a voice's matrix to the two-state chain of its probability
+func (c *MarkovTrigController) rebuild(voice string) {
+	states := c.BaseStates(voice)
+	prob := c.Value(voice + "/prob")
+	c.matrices[voice] = twoStateMatrix(states[0], states[1], prob)
+	c.builtFrom[voice] = prob
+}
 
-	// Kick
-	kickPrefix := "  "
-	if c.activeSynth == 0 {
-		kickPrefix = "> "
+// SetTransition sets one probability, normalises its row and sends the row
+// Returns false if the change would leave the state with nowhere to go
+func (c *MarkovTrigController) SetTransition(voice, from, to string, p float64) bool {
+	m := c.Matrix(voice)
+	if !m.HasState(from) || !m.HasState(to) {
+		return false
 	}
-	status.WriteString(fmt.Sprintf("%s1. Kick: %.0f%%\n", kickPrefix, c.kickProb*100))
 
-	// Snare
-	snarePrefix := "  "
-	if c.activeSynth == 1 {
-		snarePrefix = "> "
+	old := m.Get(from, to)
+	m.Set(from, to, math.Max(0, math.Min(1, p)))
+	if m.RowSum(from) <= 0 {
+		m.Set(from, to, old)
+		return false
 	}
-	status.WriteString(fmt.Sprintf("%s2. Snare: %.0f%%\n", snarePrefix, c.snareProb*100))
+	m.Normalize(from)
+	c.sendRow(voice, from)
+	return true
+}
 
-	// Hihat
-	hihatPrefix := "  "
-	if c.activeSynth == 2 {
-		hihatPrefix = "> "
+// AddState adds an extra state whose row starts as a copy of the on state's row
+// Nothing moves into the new state until a transition to it is raised
+func (c *MarkovTrigController) AddState(voice, state string) bool {
+	m := c.Matrix(voice)
+	if m.HasState(state) || !slices.Contains(markovTrigExtraStates, state) {
+		return false
 	}
-	status.WriteString(fmt.Sprintf("%s3. Hihat: %.0f%%\n", hihatPrefix, c.hihatProb*100))
 
-	// FM1
-	fm1Prefix := "  "
-	if c.activeSynth == 3 {
-		fm1Prefix = "> "
+	on := c.BaseStates(voice)[0]
+	for _, to := range m.States() {
+		m.Set(state, to, m.Get(on, to))
 	}
-	status.WriteString(fmt.Sprintf("%s4. FM1: %.0f%%\n", fm1Prefix, c.fm1Prob*100))
+	m.Set(state, state, 0)
+	c.sendRow(voice, state)
+	return true
+}
 
-	// FM2
-	fm2Prefix := "  "
-	if c.activeSynth == 4 {
-		fm2Prefix = "> "
+// RemoveState removes an extra state from a voice's chain
+func (c *MarkovTrigController) RemoveState(voice, state string) bool {
+	m := c.Matrix(voice)
+	if !m.HasState(state) || slices.Contains(c.BaseStates(voice), state) {
+		return false
 	}
-	status.WriteString(fmt.Sprintf("%s5. FM2: %.0f%%", fm2Prefix, c.fm2Prob*100))
 
-	if c.debug {
-		status.WriteString("\nDEBUG")
-	}
+	m.RemoveState(state)
+	address := c.voiceAddress(voice, "remove_state")
+	c.sclangAdapter.SendLatestKeyed(address+" "+state, address, state)
+	return true
+}
 
-	return status.String()
+// QueryMatrix asks sclang to report a voice's matrix on <voice>/matrix
+func (c *MarkovTrigController) QueryMatrix(voice string) {
+	c.sclangAdapter.Send(c.voiceAddress(voice, "matrix/query"))
 }
 
-// HandleInput processes controller-specific input
-func (c *MarkovTrigController) HandleInput(msg tea.KeyMsg) bool {
-	switch msg.String() {
-	case " ":
-		// Toggle pause/resume
-		if c.isPlaying {
-			c.sclangAdapter.Send("/pattern/markov_trig/pause")
-			c.isPlaying = false
-		} else {
-			c.sclangAdapter.Send("/pattern/markov_trig/resume")
-			c.isPlaying = true
-		}
-		return true
-
-	case "p":
-		// Toggle play/stop (reset position)
-		if c.isPlaying {
-			c.sclangAdapter.Send("/pattern/markov_trig/stop")
-			c.isPlaying = false
-		} else {
-			c.sclangAdapter.Send("/pattern/markov_trig/play")
-			c.isPlaying = true
-		}
-		return true
-
-	case "1":
-		// Select kick synth
-		c.activeSynth = 0
-		return true
-
-	case "2":
-		// Select snare synth
-		c.activeSynth = 1
-		return true
-
-	case "3":
-		// Select hihat synth
-		c.activeSynth = 2
-		return true
-
-	case "4":
-		// Select fm1 synth
-		c.activeSynth = 3
-		return true
-
-	case "5":
-		// Select fm2 synth
-		c.activeSynth = 4
-		return true
-
-	case "d":
-		// Decrease base event duration
-		if c.baseEventDur > 0.025 {
-			c.baseEventDur -= 0.005
-			c.sclangAdapter.Send("/pattern/markov_trig/base_event_dur", float32(c.baseEventDur))
-		}
-		return true
+// Query asks sclang for the pattern state and every voice's matrix
+func (c *MarkovTrigController) Query() {
+	c.ParamController.Query()
+	for _, voice := range c.Voices() {
+		c.QueryMatrix(voice.Name)
+	}
+}
 
-	case "D":
-		// Increase base event duration
-		if c.baseEventDur < 1.0 {
-			c.baseEventDur += 0.005
-			c.sclangAdapter.Send("/pattern/markov_trig/base_event_dur", float32(c.baseEventDur))
+// PushAll re-sends every parameter, then every matrix row
+// (the probabilities rebuild two-state chains in sclang, the rows restore edits)
+func (c *MarkovTrigController) PushAll() {
+	c.ParamController.PushAll()
+	for _, voice := range c.Voices() {
+		for _, from := range c.Matrix(voice.Name).States() {
+			c.sendRow(voice.Name, from)
 		}
-		return true
+	}
+}
 
-	case "r":
-		// Decrease phrase length
-		if c.phraseLength > 4 {
-			c.phraseLength--
-			c.sclangAdapter.Send("/pattern/markov_trig/phrase_length", int32(c.phraseLength))
+// HandleOSC adopts matrices reported by sclang, then defers to ParamController
+func (c *MarkovTrigController) HandleOSC(msg *osc.Message) bool {
+	if rest, ok := strings.CutPrefix(msg.Address, c.Namespace()+"/"); ok {
+		if voice, ok := strings.CutSuffix(rest, "/matrix"); ok && c.matrices[voice] != nil {
+			c.adoptMatrix(voice, msg)
+			return true
 		}
-		return true
+	}
 
-	case "R":
-		// Increase phrase length
-		if c.phraseLength < 64 {
-			c.phraseLength++
-			c.sclangAdapter.Send("/pattern/markov_trig/phrase_length", int32(c.phraseLength))
-		}
-		return true
-
-	case "e":
-		// Decrease probability for active synth
-		switch c.activeSynth {
-		case 0: // kick
-			if c.kickProb > 0.0 {
-				c.kickProb -= 0.1
-				if c.kickProb < 0.0 {
-					c.kickProb = 0.0
-				}
-				c.sclangAdapter.Send("/pattern/markov_trig/kick/prob", float32(c.kickProb))
-			}
-		case 1: // snare
-			if c.snareProb > 0.0 {
-				c.snareProb -= 0.1
-				if c.snareProb < 0.0 {
-					c.snareProb = 0.0
-				}
-				c.sclangAdapter.Send("/pattern/markov_trig/snare/prob", float32(c.snareProb))
-			}
-		case 2: // hihat
-			if c.hihatProb > 0.0 {
-				c.hihatProb -= 0.1
-				if c.hihatProb < 0.0 {
-					c.hihatProb = 0.0
-				}
-				c.sclangAdapter.Send("/pattern/markov_trig/hihat/prob", float32(c.hihatProb))
-			}
-		case 3: // fm1
-			if c.fm1Prob > 0.0 {
-				c.fm1Prob -= 0.1
-				if c.fm1Prob < 0.0 {
-					c.fm1Prob = 0.0
-				}
-				c.sclangAdapter.Send("/pattern/markov_trig/fm1/prob", float32(c.fm1Prob))
-			}
-		case 4: // fm2
-			if c.fm2Prob > 0.0 {
-				c.fm2Prob -= 0.1
-				if c.fm2Prob < 0.0 {
-					c.fm2Prob = 0.0
-				}
-				c.sclangAdapter.Send("/pattern/markov_trig/fm2/prob", float32(c.fm2Prob))
-			}
-		}
-		return true
-
-	case "E":
-		// Increase probability for active synth
-		switch c.activeSynth {
-		case 0: // kick
-			if c.kickProb < 1.0 {
-				c.kickProb += 0.1
-				if c.kickProb > 1.0 {
-					c.kickProb = 1.0
-				}
-				c.sclangAdapter.Send("/pattern/markov_trig/kick/prob", float32(c.kickProb))
-			}
-		case 1: // snare
-			if c.snareProb < 1.0 {
-				c.snareProb += 0.1
-				if c.snareProb > 1.0 {
-					c.snareProb = 1.0
-				}
-				c.sclangAdapter.Send("/pattern/markov_trig/snare/prob", float32(c.snareProb))
-			}
-		case 2: // hihat
-			if c.hihatProb < 1.0 {
-				c.hihatProb += 0.1
-				if c.hihatProb > 1.0 {
-					c.hihatProb = 1.0
-				}
-				c.sclangAdapter.Send("/pattern/markov_trig/hihat/prob", float32(c.hihatProb))
-			}
-		case 3: // fm1
-			if c.fm1Prob < 1.0 {
-				c.fm1Prob += 0.1
-				if c.fm1Prob > 1.0 {
-					c.fm1Prob = 1.0
-				}
-				c.sclangAdapter.Send("/pattern/markov_trig/fm1/prob", float32(c.fm1Prob))
-			}
-		case 4: // fm2
-			if c.fm2Prob < 1.0 {
-				c.fm2Prob += 0.1
-				if c.fm2Prob > 1.0 {
-					c.fm2Prob = 1.0
-				}
-				c.sclangAdapter.Send("/pattern/markov_trig/fm2/prob", float32(c.fm2Prob))
-			}
+	return c.ParamController.HandleOSC(msg)
+}
+
+// adoptMatrix replaces a voice's matrix with a reply of from/to/probability triples
+// Base states come first, then extra states, then anything else sclang reports
+func (c *MarkovTrigController) adoptMatrix(voice string, msg *osc.Message) {
+	m := NewTransitionMatrix(append(slices.Clone(c.BaseStates(voice)), markovTrigExtraStates...)...)
+	for i := 0; i+2 < len(msg.Arguments); i += 3 {
+		from, okFrom := msg.Arguments[i].(string)
+		to, okTo := msg.Arguments[i+1].(string)
+		p, okP := numericArg(msg.Arguments[i+2])
+		if okFrom && okTo && okP {
+			m.Set(from, to, p)
 		}
-		return true
-
-	case "x":
-		// Toggle debug
-		c.debug = !c.debug
-		debugInt := int32(0)
-		if c.debug {
-			debugInt = 1
+	}
+
+	// Drop extra states the chain doesn't use
+	for _, state := range markovTrigExtraStates {
+		if m.RowSum(state) == 0 {
+			m.RemoveState(state)
 		}
-		c.sclangAdapter.Send("/pattern/markov_trig/debug", debugInt)
-		return true
 	}
 
-	return false
+	c.matrices[voice] = m
+	c.builtFrom[voice] = c.Value(voice + "/prob")
+}
+
+// sendRow sends one row of a voice's matrix to <voice>/transition
+// Rows share the address, so a failed send is retried per row
+func (c *MarkovTrigController) sendRow(voice, from string) {
+	address := c.voiceAddress(voice, "transition")
+	args := append([]interface{}{from}, c.matrices[voice].Row(from)...)
+	c.sclangAdapter.SendLatestKeyed(address+" "+from, address, args...)
 }
 
-// Quit stops the pattern and resets to defaults
-func (c *MarkovTrigController) Quit() {
-	c.sclangAdapter.Send("/pattern/markov_trig/reset")
-	c.isPlaying = false
+// voiceAddress returns the OSC address of a per-voice command
+func (c *MarkovTrigController) voiceAddress(voice, command string) string {
+	return c.Namespace() + "/" + voice + "/" + command
 }
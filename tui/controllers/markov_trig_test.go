@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"forbidden_sequencer/adapter"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// fakeSClang returns an adapter sending to a local UDP socket, and that socket
+func fakeSClang(t *testing.T) (*adapter.OSCAdapter, *net.UDPConn) {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to start fake sclang: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	sclangAdapter, err := adapter.NewOSCAdapter("127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port)
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+	t.Cleanup(func() { sclangAdapter.Close() })
+	return sclangAdapter, conn
+}
+
+// receivedAddresses drains what the fake sclang has received so far
+func receivedAddresses(t *testing.T, conn *net.UDPConn) []string {
+	t.Helper()
+	var addresses []string
+	buf := make([]byte, 65535)
+	for {
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return addresses
+		}
+		packet, err := osc.ParsePacket(string(buf[:n]))
+		if msg, ok := packet.(*osc.Message); err == nil && ok {
+			addresses = append(addresses, msg.Address)
+		}
+	}
+}
+
+// stateReply builds a /state reply from the controller's current values
+func stateReply(c *MarkovTrigController) *osc.Message {
+	msg := osc.NewMessage(c.Namespace() + "/state")
+	for _, p := range c.Params() {
+		msg.Append(p.Name, float32(c.Value(p.Name)))
+	}
+	return msg
+}
+
+func TestMarkovTrigResyncPushesMatrices(t *testing.T) {
+	sclangAdapter, conn := fakeSClang(t)
+	c := NewMarkovTrigController(sclangAdapter)
+
+	// Agree on a state, then edit locally so a stale report triggers a push
+	stale := stateReply(c)
+	c.HandleOSC(stale)
+	p := c.Params()[0]
+	c.SetValue(p.Name, p.Stepped(c.Value(p.Name), 1))
+	receivedAddresses(t, conn)
+
+	c.HandleOSC(stale)
+
+	rows := 0
+	for _, address := range receivedAddresses(t, conn) {
+		if strings.HasSuffix(address, "/transition") {
+			rows++
+		}
+	}
+	if rows == 0 {
+		t.Error("resync after a stale /state didn't re-send the matrix rows")
+	}
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import "strings"
+
+// VoiceMuter is implemented by controllers whose voices can be muted and soloed
+// Muting keeps every parameter; sclang just stops triggering the voice
+type VoiceMuter interface {
+	// Voices returns the voices that can be muted
+	Voices() []Voice
+
+	// ActiveVoice returns the selected voice
+	ActiveVoice() Voice
+
+	// VoiceMuted reports whether a voice is muted by the user
+	VoiceMuted(voice string) bool
+
+	// SetVoiceMuted mutes or unmutes a voice
+	SetVoiceMuted(voice string, muted bool)
+
+	// SetSolo silences every voice except solo ("" silences them all, for a
+	// solo in another pattern). An inactive solo unsilences them
+	SetSolo(solo string, active bool)
+}
+
+// voiceMutes tracks which voices are muted, soloed and silenced in sclang
+type voiceMutes struct {
+	muted    map[string]bool // muted by the user
+	soloing  bool            // a solo is active somewhere
+	solo     string          // soloed voice of this pattern ("" if it is elsewhere)
+	silenced map[string]bool // last mute state sent to sclang
+}
+
+// silences reports whether a voice should be silent, by mute or by solo
+func (m *voiceMutes) silences(voice string) bool {
+	if m.muted[voice] {
+		return true
+	}
+	return m.soloing && voice != m.solo
+}
+
+// muteSuffix is the address suffix of a voice's mute, e.g. "kick/mute"
+func muteSuffix(voice string) string {
+	return voice + "/mute"
+}
+
+// VoiceMuted reports whether a voice is muted by the user
+func (c *ParamController) VoiceMuted(voice string) bool {
+	return c.mutes.muted[voice]
+}
+
+// SetVoiceMuted mutes or unmutes a voice
+func (c *ParamController) SetVoiceMuted(voice string, muted bool) {
+	if c.mutes.muted == nil {
+		c.mutes.muted = make(map[string]bool)
+	}
+	c.mutes.muted[voice] = muted
+	c.syncMutes()
+}
+
+// SetSolo silences every voice except solo ("" silences them all); inactive lifts the solo
+func (c *ParamController) SetSolo(solo string, active bool) {
+	c.mutes.soloing = active
+	c.mutes.solo = ""
+	if active {
+		c.mutes.solo = solo
+	}
+	c.syncMutes()
+}
+
+// voiceMuteState describes a voice's mute for the status ("" if audible)
+func (c *ParamController) voiceMuteState(voice string) string {
+	switch {
+	case c.mutes.soloing && voice == c.mutes.solo:
+		return "SOLO"
+	case c.mutes.muted[voice]:
+		return "MUTED"
+	case c.mutes.silences(voice):
+		return "silenced"
+	}
+	return ""
+}
+
+// syncMutes sends the mute of every voice whose state sclang doesn't have yet
+func (c *ParamController) syncMutes() {
+	if c.mutes.silenced == nil {
+		c.mutes.silenced = make(map[string]bool)
+	}
+	for _, voice := range c.schema.Voices {
+		silent := c.mutes.silences(voice.Name)
+		if silent == c.mutes.silenced[voice.Name] {
+			continue
+		}
+		c.mutes.silenced[voice.Name] = silent
+		c.sendVoiceMute(voice.Name, silent)
+	}
+}
+
+// sendVoiceMute transmits a voice's mute
+func (c *ParamController) sendVoiceMute(voice string, silent bool) {
+	arg := int32(0)
+	if silent {
+		arg = 1
+	}
+	c.sclangAdapter.SendLatest(c.schema.Namespace+"/"+muteSuffix(voice), arg)
+}
+
+// applyMuteState reconciles voice mutes reported on /state and removes them from state
+// Without a solo, sclang's mutes are adopted; with one, the solo is re-sent
+func (c *ParamController) applyMuteState(state map[string]float64) {
+	if c.mutes.silenced == nil {
+		c.mutes.silenced = make(map[string]bool)
+	}
+	for key, v := range state {
+		voice, ok := strings.CutSuffix(key, "/mute")
+		if !ok || c.Param(key) != nil {
+			continue
+		}
+		delete(state, key)
+
+		reported := v == 1
+		c.mutes.silenced[voice] = reported
+		if reported != c.mutes.silences(voice) && !c.mutes.soloing {
+			c.SetVoiceMuted(voice, reported)
+		}
+	}
+	c.syncMutes()
+}
+
+// resetMutes clears user mutes and any solo after sclang has reset the pattern
+// A stopped pattern takes no part in a solo; it is applied again if it restarts
+func (c *ParamController) resetMutes() {
+	c.mutes = voiceMutes{}
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+)
+
+// ParamType describes how a parameter value is stepped and encoded over OSC
+type ParamType int
+
+const (
+	ParamFloat ParamType = iota // continuous value, sent as float32
+	ParamInt                    // whole number, sent as int32
+	ParamBool                   // on/off flag, toggled and sent as int32 0/1
+)
+
+// Voice is a named group of parameters (usually one synth in the pattern)
+type Voice struct {
+	Name  string `json:"name" yaml:"name"`   // identifier used in OSC addresses, e.g. "kick"
+	Label string `json:"label" yaml:"label"` // display name, e.g. "Kick"
+}
+
+// Param describes a single pattern parameter
+// Values are stored as float64 regardless of type
+type Param struct {
+	Name    string    // address suffix under the pattern namespace, e.g. "kick/curve"
+	Label   string    // display label in the status view, e.g. "curve"
+	Help    string    // keybinding description, e.g. "curve"
+	Type    ParamType // float, int or bool
+	Min     float64   // lower bound (inclusive)
+	Max     float64   // upper bound (inclusive)
+	Step    float64   // amount added or removed per keypress
+	Default float64   // initial value, matching the .scd defaults
+	Voice   string    // voice the parameter belongs to ("" for pattern-wide)
+	DecKey  string    // key that decreases the value (or toggles a bool)
+	IncKey  string    // key that increases the value
+
+	// Transient parameters (e.g. debug) are not captured in presets
+	Transient bool
+
+	// Format renders the value for display (defaults depend on Type)
+	Format func(v float64) string
+
+	// Bounds overrides Min/Max with limits derived from other parameter values
+	Bounds func(values map[string]float64) (min, max float64)
+
+	// Address overrides the address suffix the value is sent to (defaults to Name)
+	// Several parameters can share one address when they feed the same message
+	Address string
+
+	// Encode overrides Arg to send the value as several arguments (e.g. a
+	// scale as its list of intervals); it sees every parameter value
+	Encode func(values map[string]float64) []interface{}
+}
+
+// limits returns the parameter's current min and max
+func (p *Param) limits(values map[string]float64) (float64, float64) {
+	if p.Bounds != nil {
+		return p.Bounds(values)
+	}
+	return p.Min, p.Max
+}
+
+// Clamp restricts v to the parameter's range and snaps it to the type
+func (p *Param) Clamp(v float64, values map[string]float64) float64 {
+	min, max := p.limits(values)
+	switch p.Type {
+	case ParamInt:
+		v = math.Round(v)
+	case ParamBool:
+		if v != 0 {
+			v = 1
+		}
+		min, max = 0, 1
+	}
+	return math.Max(min, math.Min(max, v))
+}
+
+// Stepped returns v moved by steps × Step, snapped to the step grid
+// Snapping avoids accumulating float error from repeated keypresses
+// (dividing by the inverse keeps e.g. 7 × 0.1 at exactly 0.7)
+func (p *Param) Stepped(v float64, steps int) float64 {
+	v += float64(steps) * p.Step
+	if p.Type == ParamFloat && p.Step > 0 {
+		v = math.Round(v/p.Step) / (1 / p.Step)
+	}
+	return v
+}
+
+// Continuous reports whether the parameter can take in-between values
+// (integers and flags only change in whole steps)
+func (p *Param) Continuous() bool {
+	return p.Type == ParamFloat
+}
+
+// Arg encodes a value as an OSC argument
+func (p *Param) Arg(v float64) interface{} {
+	if p.Type == ParamFloat {
+		return float32(v)
+	}
+	return int32(math.Round(v))
+}
+
+// FormatValue renders a value for display
+func (p *Param) FormatValue(v float64) string {
+	if p.Format != nil {
+		return p.Format(v)
+	}
+	switch p.Type {
+	case ParamInt:
+		return fmt.Sprintf("%d", int(math.Round(v)))
+	case ParamBool:
+		if v != 0 {
+			return "on"
+		}
+		return "off"
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// FormatFloat returns a formatter with the given number of decimals and suffix
+func FormatFloat(decimals int, suffix string) func(v float64) string {
+	return func(v float64) string {
+		return fmt.Sprintf("%.*f%s", decimals, v, suffix)
+	}
+}
+
+// FormatSigned renders whole numbers with an explicit sign (e.g. "+2")
+func FormatSigned(v float64) string {
+	return fmt.Sprintf("%+d", int(math.Round(v)))
+}
+
+// FormatPercent renders a 0-1 value as a percentage
+func FormatPercent(v float64) string {
+	return fmt.Sprintf("%.0f%%", v*100)
+}
+
+// FormatPan renders a -1 to 1 pan position (e.g. "L30", "C", "R100")
+func FormatPan(v float64) string {
+	percent := int(math.Round(v * 100))
+	switch {
+	case percent < 0:
+		return fmt.Sprintf("L%d", -percent)
+	case percent > 0:
+		return fmt.Sprintf("R%d", percent)
+	}
+	return "C"
+}
+
+// FormatMillis renders a signed millisecond offset (e.g. "+12ms")
+func FormatMillis(v float64) string {
+	return fmt.Sprintf("%+dms", int(math.Round(v)))
+}
+
+// FeelParams returns the swing, humanize and humanize seed parameters of a
+// step-based pattern. Swing delays every second step by a share of a step;
+// humanize jitters timing and velocity from a seeded random stream
+func FeelParams() []*Param {
+	return []*Param{
+		{Name: "swing", Label: "Swing", Help: "swing", Type: ParamFloat, Min: 0, Max: 0.5, Step: 0.05, Default: 0, DecKey: "w", IncKey: "W", Format: FormatPercent},
+		{Name: "humanize", Label: "Humanize", Help: "humanize", Type: ParamFloat, Min: 0, Max: 1, Step: 0.1, Default: 0, DecKey: "h", IncKey: "H", Format: FormatPercent},
+		{Name: "humanize_seed", Label: "Seed", Help: "humanize seed", Type: ParamInt, Min: 1, Max: 999, Step: 1, Default: 1, DecKey: "j", IncKey: "J"},
+	}
+}
+
+// NudgeParam returns a voice's timing offset in milliseconds
+func NudgeParam(voice string) *Param {
+	return &Param{Name: voice + "/nudge", Label: "nudge", Help: "nudge", Type: ParamInt, Min: -50, Max: 50, Step: 1, Default: 0, Voice: voice, DecKey: "g", IncKey: "G", Format: FormatMillis}
+}
+
+// MixParams returns a voice's amp, pan and reverb send parameters
+// Send is the share of the voice routed through the reverb rather than dry
+func MixParams(voice string, amp, send float64) []*Param {
+	return []*Param{
+		{Name: voice + "/amp", Label: "amp", Help: "amp", Type: ParamFloat, Min: 0, Max: 1, Step: 0.05, Default: amp, Voice: voice, DecKey: "a", IncKey: "A", Format: FormatPercent},
+		{Name: voice + "/pan", Label: "pan", Help: "pan", Type: ParamFloat, Min: -1, Max: 1, Step: 0.1, Default: 0, Voice: voice, DecKey: "b", IncKey: "B", Format: FormatPan},
+		{Name: voice + "/send", Label: "send", Help: "reverb send", Type: ParamFloat, Min: 0, Max: 1, Step: 0.05, Default: send, Voice: voice, DecKey: "f", IncKey: "F", Format: FormatPercent},
+	}
+}
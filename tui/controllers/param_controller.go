@@ -0,0 +1,640 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+
+	"forbidden_sequencer/adapter"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// Schema declares everything a ParamController needs to drive a pattern
+type Schema struct {
+	Name        string    // display name, e.g. "Curve Time"
+	Namespace   string    // OSC namespace, e.g. "/pattern/curve_time"
+	Voices      []Voice   // selectable voices (keys 1-9), may be empty
+	Params      []*Param  // parameters in display and keybinding order
+	PhraseParam string    // parameter holding events per phrase, used to show phrase duration
+	TempoParam  string    // parameter holding the event duration in seconds, driven by the global clock
+	Transport   Transport // transport command addresses (defaults when empty)
+
+	// PhraseEvents derives the events per phrase when no single parameter holds it
+	PhraseEvents func(values map[string]float64) float64
+}
+
+// Transport maps transport actions to OSC address suffixes under the namespace
+// Empty fields fall back to the action name (e.g. Play -> "play")
+type Transport struct {
+	Play   string `json:"play" yaml:"play"`
+	Stop   string `json:"stop" yaml:"stop"`
+	Pause  string `json:"pause" yaml:"pause"`
+	Resume string `json:"resume" yaml:"resume"`
+	Reset  string `json:"reset" yaml:"reset"`
+	Query  string `json:"query" yaml:"query"`
+	Mute   string `json:"mute" yaml:"mute"`
+}
+
+// command returns the address suffix for a transport action
+func (t Transport) command(action string) string {
+	overrides := map[string]string{
+		"play":   t.Play,
+		"stop":   t.Stop,
+		"pause":  t.Pause,
+		"resume": t.Resume,
+		"reset":  t.Reset,
+		"query":  t.Query,
+		"mute":   t.Mute,
+	}
+	if suffix := overrides[action]; suffix != "" {
+		return suffix
+	}
+	return action
+}
+
+// ParamController drives a pattern in sclang from a declarative Schema
+// Keybindings, status rendering, clamping and OSC sending all come from the
+// schema, so adding a pattern parameter is a data change
+type ParamController struct {
+	schema        Schema
+	sclangAdapter *adapter.OSCAdapter
+	values        map[string]float64
+	activeVoice   int
+	isPlaying     bool
+	muted         bool
+	mutes         voiceMutes
+	clocked       bool // TempoParam follows the global clock
+	sync          stateSync
+	history       history
+	pushAll       func() // PushAll of the embedding controller, used to resync sclang
+}
+
+// NewParamController creates a controller for the given schema
+// All parameters start at their defaults
+func NewParamController(schema Schema, sclangAdapter *adapter.OSCAdapter) *ParamController {
+	c := &ParamController{
+		schema:        schema,
+		sclangAdapter: sclangAdapter,
+		values:        make(map[string]float64, len(schema.Params)),
+	}
+	c.pushAll = c.PushAll
+	for _, p := range schema.Params {
+		c.values[p.Name] = p.Default
+	}
+	return c
+}
+
+// GetName returns the display name
+func (c *ParamController) GetName() string {
+	return c.schema.Name
+}
+
+// Namespace returns the OSC namespace of the pattern
+func (c *ParamController) Namespace() string {
+	return c.schema.Namespace
+}
+
+// Params returns the parameter descriptors
+func (c *ParamController) Params() []*Param {
+	return c.schema.Params
+}
+
+// Param returns the descriptor for a parameter name, or nil
+func (c *ParamController) Param(name string) *Param {
+	for _, p := range c.schema.Params {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Voices returns the selectable voices
+func (c *ParamController) Voices() []Voice {
+	return c.schema.Voices
+}
+
+// ActiveVoice returns the currently selected voice (zero Voice if there are none)
+func (c *ParamController) ActiveVoice() Voice {
+	if c.activeVoice < len(c.schema.Voices) {
+		return c.schema.Voices[c.activeVoice]
+	}
+	return Voice{}
+}
+
+// Value returns the current value of a parameter
+func (c *ParamController) Value(name string) float64 {
+	return c.values[name]
+}
+
+// Limits returns a parameter's current min and max
+func (c *ParamController) Limits(name string) (float64, float64) {
+	p := c.Param(name)
+	if p == nil {
+		return 0, 0
+	}
+	if p.Type == ParamBool {
+		return 0, 1
+	}
+	return p.limits(c.values)
+}
+
+// SetValue clamps and stores a parameter value and sends it to sclang
+// Returns true if the stored value changed
+func (c *ParamController) SetValue(name string, v float64) bool {
+	return len(c.set(name, v)) > 0
+}
+
+// set is SetValue returning every change made, including parameters whose
+// bounds depend on this one (e.g. euclid pulses when the steps shrink)
+func (c *ParamController) set(name string, v float64) command {
+	p := c.Param(name)
+	if p == nil {
+		return nil
+	}
+
+	v = p.Clamp(v, c.values)
+	old := c.values[name]
+	if v == old {
+		return nil
+	}
+
+	c.values[name] = v
+	c.send(p)
+	return append(command{{name: name, old: old, new: v}}, c.clampDependents()...)
+}
+
+// assign stores and sends several values at once, returning the changes
+// Parameters bounded by others are clamped last, against the new values
+func (c *ParamController) assign(values map[string]float64) command {
+	var cmd command
+	for _, p := range c.clampOrder() {
+		v, ok := values[p.Name]
+		if !ok {
+			continue
+		}
+		old := c.values[p.Name]
+		c.values[p.Name] = p.Clamp(v, c.values)
+		c.send(p)
+		if c.values[p.Name] != old {
+			cmd = append(cmd, paramChange{name: p.Name, old: old, new: c.values[p.Name]})
+		}
+	}
+	return append(cmd, c.clampDependents()...)
+}
+
+// clampOrder returns the parameters with those whose Bounds read other values last
+func (c *ParamController) clampOrder() []*Param {
+	params := make([]*Param, 0, len(c.schema.Params))
+	for _, p := range c.schema.Params {
+		if p.Bounds == nil {
+			params = append(params, p)
+		}
+	}
+	for _, p := range c.schema.Params {
+		if p.Bounds != nil {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// clampDependents re-clamps parameters whose Bounds read other values and
+// sends those that moved, returning the changes
+func (c *ParamController) clampDependents() command {
+	var cmd command
+	for _, p := range c.schema.Params {
+		if p.Bounds == nil {
+			continue
+		}
+		old := c.values[p.Name]
+		if v := p.Clamp(old, c.values); v != old {
+			c.values[p.Name] = v
+			c.send(p)
+			cmd = append(cmd, paramChange{name: p.Name, old: old, new: v})
+		}
+	}
+	return cmd
+}
+
+// SetEventDuration sets the tempo parameter from the global clock
+// From then on the tempo is owned by the clock: it is left out of presets
+// and undo, and sclang is corrected if it reports a different value
+func (c *ParamController) SetEventDuration(seconds float64) {
+	if c.schema.TempoParam == "" {
+		return
+	}
+	c.clocked = true
+	c.SetValue(c.schema.TempoParam, seconds)
+}
+
+// followsClock reports whether a parameter is driven by the global clock
+func (c *ParamController) followsClock(p *Param) bool {
+	return c.clocked && p.Name == c.schema.TempoParam
+}
+
+// ID returns a stable identifier for the pattern (last segment of the namespace)
+func (c *ParamController) ID() string {
+	return path.Base(c.schema.Namespace)
+}
+
+// Snapshot returns the current values of every non-transient parameter
+func (c *ParamController) Snapshot() map[string]float64 {
+	snapshot := make(map[string]float64, len(c.schema.Params))
+	for _, p := range c.schema.Params {
+		if !p.Transient && !c.followsClock(p) {
+			snapshot[p.Name] = c.values[p.Name]
+		}
+	}
+	return snapshot
+}
+
+// Restore sets every parameter present in a snapshot and pushes it to sclang
+// Unknown names are ignored so presets survive schema changes
+// The whole recall is undoable as a single step
+func (c *ParamController) Restore(snapshot map[string]float64) {
+	values := make(map[string]float64, len(snapshot))
+	for _, p := range c.schema.Params {
+		if v, ok := snapshot[p.Name]; ok && !p.Transient && !c.followsClock(p) {
+			values[p.Name] = v
+		}
+	}
+	c.history.record(c.assign(values))
+}
+
+// setRecorded sets a value like SetValue and records it for undo
+func (c *ParamController) setRecorded(name string, v float64) {
+	c.history.record(c.set(name, v))
+}
+
+// Undo reverts the most recent recorded change and re-sends the old values
+// Returns false if there is nothing to undo
+func (c *ParamController) Undo() bool {
+	cmd, ok := c.history.popUndo()
+	if !ok {
+		return false
+	}
+	values := make(map[string]float64, len(cmd))
+	for i := len(cmd) - 1; i >= 0; i-- {
+		values[cmd[i].name] = cmd[i].old
+	}
+	c.assign(values)
+	return true
+}
+
+// Redo re-applies the most recently undone change
+// Returns false if there is nothing to redo
+func (c *ParamController) Redo() bool {
+	cmd, ok := c.history.popRedo()
+	if !ok {
+		return false
+	}
+	values := make(map[string]float64, len(cmd))
+	for _, change := range cmd {
+		values[change.name] = change.new
+	}
+	c.assign(values)
+	return true
+}
+
+// IsPlaying reports whether the pattern is playing
+func (c *ParamController) IsPlaying() bool {
+	return c.isPlaying
+}
+
+// Play starts the pattern from the beginning
+func (c *ParamController) Play() {
+	c.sendCommand("play")
+	c.isPlaying = true
+}
+
+// Stop stops the pattern and rewinds it
+func (c *ParamController) Stop() {
+	c.sendCommand("stop")
+	c.isPlaying = false
+}
+
+// Muted reports whether the pattern is silenced
+func (c *ParamController) Muted() bool {
+	return c.muted
+}
+
+// SetMuted silences the pattern while it keeps running, or unmutes it
+func (c *ParamController) SetMuted(muted bool) {
+	c.muted = muted
+	arg := int32(0)
+	if muted {
+		arg = 1
+	}
+	c.sclangAdapter.SendLatest(c.schema.Namespace+"/"+c.schema.Transport.command("mute"), arg)
+}
+
+// GetKeybindings returns the controller-specific controls
+func (c *ParamController) GetKeybindings() string {
+	lines := []string{
+		"p: play/stop",
+		"space: pause/resume",
+		"u/U: undo/redo",
+	}
+
+	switch n := len(c.schema.Voices); {
+	case n == 1:
+		lines = append(lines, "1: select synth")
+	case n > 1:
+		lines = append(lines, fmt.Sprintf("1-%d: select synth", n))
+	}
+	if len(c.schema.Voices) > 0 {
+		lines = append(lines, "m: mute (active synth)")
+	}
+
+	// One line per key pair; voice params share keys across voices
+	seen := make(map[string]bool)
+	for _, p := range c.schema.Params {
+		keys := p.DecKey
+		if p.IncKey != "" {
+			keys += "/" + p.IncKey
+		}
+		if keys == "" || seen[keys] {
+			continue
+		}
+		seen[keys] = true
+
+		help := p.Help
+		if p.Voice != "" {
+			help += " (active synth)"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", keys, help))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// GetStatus returns the current state
+func (c *ParamController) GetStatus() string {
+	var status strings.Builder
+
+	// Pattern-wide parameters
+	status.WriteString(c.summary())
+
+	// Voices section
+	if len(c.schema.Voices) > 0 {
+		status.WriteString("\n\nSynths:")
+		for i, voice := range c.schema.Voices {
+			prefix := "  "
+			if i == c.activeVoice {
+				prefix = "> "
+			}
+			status.WriteString(fmt.Sprintf("\n%s%d. %s: %s", prefix, i+1, voice.Label, c.voiceSummary(voice.Name)))
+			if mute := c.voiceMuteState(voice.Name); mute != "" {
+				status.WriteString(" [" + mute + "]")
+			}
+		}
+	}
+
+	// Flags that are switched on (e.g. DEBUG)
+	for _, p := range c.schema.Params {
+		if p.Type == ParamBool && p.Voice == "" && c.values[p.Name] != 0 {
+			status.WriteString("\n" + strings.ToUpper(p.Label))
+		}
+	}
+
+	return status.String()
+}
+
+// summary renders the pattern-wide numeric parameters on one line
+func (c *ParamController) summary() string {
+	var parts []string
+	for _, p := range c.schema.Params {
+		if p.Voice != "" || p.Type == ParamBool {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", p.Label, p.FormatValue(c.values[p.Name])))
+	}
+	if c.schema.PhraseParam != "" || c.schema.PhraseEvents != nil {
+		parts = append(parts, fmt.Sprintf("Phrase: %.2fs", c.PhraseDuration()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// voiceSummary renders the parameters of one voice
+func (c *ParamController) voiceSummary(voice string) string {
+	var parts []string
+	for _, p := range c.schema.Params {
+		if p.Voice != voice {
+			continue
+		}
+		if p.Label == "" {
+			parts = append(parts, p.FormatValue(c.values[p.Name]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s=%s", p.Label, p.FormatValue(c.values[p.Name])))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// PhraseDuration returns the phrase length in seconds (base event dur × events per phrase)
+func (c *ParamController) PhraseDuration() float64 {
+	var events float64
+	switch {
+	case c.schema.PhraseParam != "":
+		events = c.values[c.schema.PhraseParam]
+	case c.schema.PhraseEvents != nil:
+		events = c.schema.PhraseEvents(c.values)
+	default:
+		return 0
+	}
+	tempo := c.schema.TempoParam
+	if tempo == "" {
+		tempo = "base_event_dur"
+	}
+	return c.values[tempo] * events
+}
+
+// HandleInput processes controller-specific input
+func (c *ParamController) HandleInput(msg tea.KeyMsg) bool {
+	key := msg.String()
+
+	switch key {
+	case " ":
+		// Toggle pause/resume
+		if c.isPlaying {
+			c.sendCommand("pause")
+			c.isPlaying = false
+		} else {
+			c.sendCommand("resume")
+			c.isPlaying = true
+		}
+		return true
+
+	case "p":
+		// Toggle play/stop (reset position)
+		if c.isPlaying {
+			c.Stop()
+		} else {
+			c.Play()
+		}
+		return true
+
+	case "u":
+		c.Undo()
+		return true
+
+	case "U":
+		c.Redo()
+		return true
+
+	case "m":
+		// Toggle mute of the active voice, keeping its settings
+		if len(c.schema.Voices) > 0 {
+			voice := c.ActiveVoice().Name
+			c.SetVoiceMuted(voice, !c.mutes.muted[voice])
+			return true
+		}
+	}
+
+	// Voice selection
+	if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+		index := int(key[0] - '1')
+		if index < len(c.schema.Voices) {
+			c.activeVoice = index
+			return true
+		}
+	}
+
+	// Parameter keys - pattern-wide params, or params of the active voice
+	handled := false
+	active := c.ActiveVoice().Name
+	for _, p := range c.schema.Params {
+		if key != p.DecKey && key != p.IncKey {
+			continue
+		}
+		handled = true
+		if p.Voice != "" && p.Voice != active {
+			continue
+		}
+
+		switch {
+		case p.Type == ParamBool && p.Transient:
+			// Flags like debug aren't worth undoing
+			c.SetValue(p.Name, 1-c.values[p.Name])
+		case p.Type == ParamBool:
+			c.setRecorded(p.Name, 1-c.values[p.Name])
+		case key == p.DecKey:
+			c.setRecorded(p.Name, p.Stepped(c.values[p.Name], -1))
+		default:
+			c.setRecorded(p.Name, p.Stepped(c.values[p.Name], 1))
+		}
+	}
+
+	return handled
+}
+
+// HandleOSC updates local state from messages reported by sclang
+func (c *ParamController) HandleOSC(msg *osc.Message) bool {
+	switch msg.Address {
+	case c.schema.Namespace + "/state":
+		// Reply to Query - reconcile with what sclang reports
+		state := parseState(msg)
+		if playing, ok := state["playing"]; ok {
+			c.isPlaying = playing == 1
+			delete(state, "playing")
+		}
+		if muted, ok := state["muted"]; ok {
+			c.muted = muted == 1
+			delete(state, "muted")
+		}
+		c.applyMuteState(state)
+		switch c.sync.resolve(c.values, state) {
+		case syncAdopt:
+			c.applyState(state)
+		case syncPush:
+			c.pushAll()
+		}
+		return true
+
+	case c.schema.Namespace + "/transport":
+		// sclang reports playing, paused or stopped
+		if state, ok := transportStateArg(msg); ok {
+			c.isPlaying = state == "playing"
+		}
+		return true
+	}
+
+	return false
+}
+
+// Query asks sclang to report the current pattern state
+func (c *ParamController) Query() {
+	c.sendCommand("query")
+}
+
+// PushAll re-sends every parameter so sclang matches the controller
+func (c *ParamController) PushAll() {
+	for _, p := range c.schema.Params {
+		c.send(p)
+	}
+}
+
+// applyState sets known parameters from a reported state
+// Values are clamped like local edits, and sclang is corrected if one was out
+// of range; the clock-driven tempo is not adopted, sclang is corrected instead
+func (c *ParamController) applyState(state map[string]float64) {
+	for _, p := range c.clampOrder() {
+		v, ok := state[p.Name]
+		if !ok {
+			continue
+		}
+		if c.followsClock(p) {
+			if math.Abs(v-c.values[p.Name]) > stateTolerance {
+				c.send(p)
+			}
+			continue
+		}
+		c.values[p.Name] = p.Clamp(v, c.values)
+		if c.values[p.Name] != v {
+			c.send(p)
+		}
+	}
+	c.clampDependents()
+}
+
+// Quit stops the pattern and resets to defaults
+func (c *ParamController) Quit() {
+	c.sendCommand("reset")
+	c.isPlaying = false
+	c.muted = false
+	c.resetMutes()
+
+	// Match sclang's defaults, so the next /state isn't taken for local edits
+	// and pushed back; the clock-driven tempo keeps following the clock
+	for _, p := range c.schema.Params {
+		if !c.followsClock(p) {
+			c.values[p.Name] = p.Default
+		}
+	}
+	c.history = history{}
+}
+
+// send transmits a parameter's current value
+// A failed send is retried with whatever value is latest by then
+func (c *ParamController) send(p *Param) {
+	address := p.Name
+	if p.Address != "" {
+		address = p.Address
+	}
+
+	args := []interface{}{p.Arg(c.values[p.Name])}
+	if p.Encode != nil {
+		args = p.Encode(c.values)
+	}
+
+	c.sclangAdapter.SendLatest(c.schema.Namespace+"/"+address, args...)
+}
+
+// sendCommand transmits an argument-less transport command such as play or stop
+func (c *ParamController) sendCommand(action string) {
+	c.sclangAdapter.Send(c.schema.Namespace + "/" + c.schema.Transport.command(action))
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+func TestApplyStateClampsReportedValues(t *testing.T) {
+	sclangAdapter, conn := fakeSClang(t)
+	c := NewCurveTimeController(sclangAdapter)
+
+	tests := []struct {
+		name     string
+		reported float64
+		want     float64
+	}{
+		{"below min", 4, 16},
+		{"above max", 99, 32},
+		{"fraction of an int", 20.4, 20},
+		{"in range", 24, 24},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.applyState(map[string]float64{"phrase_events": tt.reported})
+			if got := c.Value("phrase_events"); got != tt.want {
+				t.Errorf("phrase_events = %g, want %g", got, tt.want)
+			}
+
+			// An out-of-range value is corrected in sclang too
+			corrected := slices.Contains(receivedAddresses(t, conn), c.Namespace()+"/phrase_events")
+			if corrected != (tt.reported != tt.want) {
+				t.Errorf("correction sent = %v, want %v", corrected, tt.reported != tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleOSCStateAdoptsClampedValues(t *testing.T) {
+	sclangAdapter, _ := fakeSClang(t)
+	c := NewCurveTimeController(sclangAdapter)
+
+	c.HandleOSC(osc.NewMessage(c.Namespace()+"/state", "playing", int32(1), "phrase_events", int32(64)))
+	if !c.IsPlaying() {
+		t.Error("reported playing state not adopted")
+	}
+	if got := c.Value("phrase_events"); got != 32 {
+		t.Errorf("phrase_events = %g, want 32", got)
+	}
+}
+
+func TestQuitResetsToDefaults(t *testing.T) {
+	sclangAdapter, conn := fakeSClang(t)
+	c := NewCurveTimeController(sclangAdapter)
+	p := c.Param("phrase_events")
+
+	// Agree with sclang, then edit
+	c.HandleOSC(osc.NewMessage(c.Namespace()+"/state", "phrase_events", int32(p.Default)))
+	c.setRecorded("phrase_events", 24)
+	c.Quit()
+
+	if got := c.Value("phrase_events"); got != p.Default {
+		t.Errorf("phrase_events after Quit = %g, want the default %g", got, p.Default)
+	}
+	if c.Undo() {
+		t.Error("an edit from before Quit could still be undone")
+	}
+	receivedAddresses(t, conn)
+
+	// sclang reports its reset state: nothing is pushed back
+	c.HandleOSC(osc.NewMessage(c.Namespace()+"/state", "phrase_events", int32(p.Default)))
+	if sent := receivedAddresses(t, conn); len(sent) != 0 {
+		t.Errorf("reset state pushed back to sclang: %v", sent)
+	}
+}
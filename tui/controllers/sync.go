@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"math"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// stateTolerance is the largest difference treated as equal when comparing
+// values, since floats travel to sclang and back as float32
+const stateTolerance = 1e-4
+
+// Syncer is implemented by controllers that can resynchronise with sclang
+type Syncer interface {
+	// Query asks sclang to report the pattern state on /pattern/<name>/state
+	Query()
+
+	// PushAll re-sends the controller's complete parameter set to sclang
+	PushAll()
+}
+
+// syncAction is the outcome of comparing local and reported state
+type syncAction int
+
+const (
+	syncNone  syncAction = iota // both sides agree
+	syncAdopt                   // take the values reported by sclang
+	syncPush                    // re-send local values to sclang
+)
+
+// stateSync tracks the last state both sides agreed on
+// While the controller has no edits since that point, sclang is authoritative;
+// once the user has changed something, local values win and are pushed back
+type stateSync struct {
+	lastSynced map[string]float64
+}
+
+// resolve decides how to reconcile local values with a reported state
+func (s *stateSync) resolve(local, remote map[string]float64) syncAction {
+	if statesEqual(local, remote) {
+		s.lastSynced = copyState(local)
+		return syncNone
+	}
+
+	if s.lastSynced == nil || statesEqual(local, s.lastSynced) {
+		// No local edits since the last sync - adopt what sclang reports
+		s.lastSynced = copyState(remote)
+		return syncAdopt
+	}
+
+	// Local edits that sclang doesn't know about (e.g. after an sclang reboot)
+	s.lastSynced = copyState(local)
+	return syncPush
+}
+
+// parseState decodes a /state reply of alternating key/value arguments
+// Keys are parameter address suffixes (e.g. "kick/curve"); values are numeric
+func parseState(msg *osc.Message) map[string]float64 {
+	state := make(map[string]float64)
+	for i := 0; i+1 < len(msg.Arguments); i += 2 {
+		key, ok := msg.Arguments[i].(string)
+		if !ok {
+			continue
+		}
+		if value, ok := numericArg(msg.Arguments[i+1]); ok {
+			state[key] = value
+		}
+	}
+	return state
+}
+
+// numericArg converts an OSC argument to float64
+func numericArg(arg interface{}) (float64, bool) {
+	switch v := arg.(type) {
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// statesEqual compares the keys present in both states
+func statesEqual(a, b map[string]float64) bool {
+	for key, av := range a {
+		if bv, ok := b[key]; ok && math.Abs(av-bv) > stateTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// copyState returns a copy of a state map
+func copyState(state map[string]float64) map[string]float64 {
+	c := make(map[string]float64, len(state))
+	for k, v := range state {
+		c[k] = v
+	}
+	return c
+}
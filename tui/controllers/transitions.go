@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"slices"
+
+	"forbidden_sequencer/markov"
+)
+
+// TransitionEditor is implemented by controllers whose voices have editable
+// Markov transition matrices
+type TransitionEditor interface {
+	// Voices returns the voices that own a matrix
+	Voices() []Voice
+
+	// Matrix returns a voice's current transition matrix
+	Matrix(voice string) *TransitionMatrix
+
+	// SetTransition sets one probability, normalises the row and sends it
+	SetTransition(voice, from, to string, p float64) bool
+
+	// AddState adds an extra state (e.g. accent) to a voice's chain
+	AddState(voice, state string) bool
+
+	// RemoveState removes an extra state from a voice's chain
+	RemoveState(voice, state string) bool
+
+	// BaseStates returns the states every chain of the voice must keep
+	BaseStates(voice string) []string
+
+	// ExtraStates returns the optional states that can be added
+	ExtraStates() []string
+
+	// QueryMatrix asks sclang to report a voice's matrix
+	QueryMatrix(voice string)
+}
+
+// TransitionMatrix is a first-order Markov chain between named states
+// Rows are "from" states and columns "to" states. Rows are normalised the
+// same way as normalizeTransitions in lib/markov.scd: divided by their sum
+// when it is positive, left alone otherwise
+type TransitionMatrix struct {
+	states []string                      // display order
+	probs  map[string]map[string]float64 // from -> to -> probability
+}
+
+// NewTransitionMatrix creates an empty matrix with the given states
+func NewTransitionMatrix(states ...string) *TransitionMatrix {
+	m := &TransitionMatrix{probs: make(map[string]map[string]float64)}
+	for _, s := range states {
+		m.addState(s)
+	}
+	return m
+}
+
+// twoStateMatrix builds the on/off chain a voice probability describes
+// Mirrors the update*Chain functions in markov_trig.scd
+func twoStateMatrix(on, off string, prob float64) *TransitionMatrix {
+	m := NewTransitionMatrix(on, off)
+	m.Set(on, on, prob)
+	m.Set(on, off, 1-prob)
+	m.Set(off, off, 1-prob)
+	m.Set(off, on, prob)
+	return m
+}
+
+// States returns the states in display order
+func (m *TransitionMatrix) States() []string {
+	return m.states
+}
+
+// HasState reports whether a state is part of the matrix
+func (m *TransitionMatrix) HasState(state string) bool {
+	return slices.Contains(m.states, state)
+}
+
+// Get returns the probability of moving from one state to another
+func (m *TransitionMatrix) Get(from, to string) float64 {
+	return m.probs[from][to]
+}
+
+// Set stores a probability, adding either state if it is new
+// The row is not normalised (see Normalize)
+func (m *TransitionMatrix) Set(from, to string, p float64) {
+	m.addState(from)
+	m.addState(to)
+	m.probs[from][to] = p
+}
+
+// RowSum returns the total probability leaving a state
+func (m *TransitionMatrix) RowSum(from string) float64 {
+	sum := 0.0
+	for _, p := range m.probs[from] {
+		sum += p
+	}
+	return sum
+}
+
+// Normalize scales a row so it sums to 1 (rows summing to 0 are left alone)
+func (m *TransitionMatrix) Normalize(from string) {
+	sum := m.RowSum(from)
+	if sum <= 0 {
+		return
+	}
+	for to, p := range m.probs[from] {
+		m.probs[from][to] = p / sum
+	}
+}
+
+// RemoveState deletes a state's row and column and renormalises the other rows
+// A row left with nothing to move to stays on its own state
+func (m *TransitionMatrix) RemoveState(state string) {
+	index := slices.Index(m.states, state)
+	if index < 0 {
+		return
+	}
+	m.states = slices.Delete(m.states, index, index+1)
+	delete(m.probs, state)
+	for _, from := range m.states {
+		delete(m.probs[from], state)
+		if m.RowSum(from) <= 0 {
+			m.probs[from][from] = 1
+		}
+		m.Normalize(from)
+	}
+}
+
+// Row returns a row as alternating to-state/probability OSC arguments
+func (m *TransitionMatrix) Row(from string) []interface{} {
+	var args []interface{}
+	for _, to := range m.states {
+		args = append(args, to, float32(m.probs[from][to]))
+	}
+	return args
+}
+
+// Chain builds a seeded Markov chain from the matrix, for previews and analysis
+func (m *TransitionMatrix) Chain(seed int64) *markov.Chain {
+	chain := markov.New(seed)
+	for _, from := range m.states {
+		for _, to := range m.states {
+			chain.SetTransition(from, to, m.probs[from][to])
+		}
+	}
+	return chain
+}
+
+// addState appends a state if it isn't already present
+func (m *TransitionMatrix) addState(state string) {
+	if m.HasState(state) {
+		return
+	}
+	m.states = append(m.states, state)
+	m.probs[state] = make(map[string]float64)
+}
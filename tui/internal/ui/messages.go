@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"time"
+
+	"forbidden_sequencer/adapter"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// OSCMsg carries an OSC message received from SuperCollider into the update loop
+type OSCMsg struct {
+	Message  *osc.Message
+	Messages <-chan *osc.Message // subscription it arrived on, waited on again
+}
+
+// waitForOSC returns a command that blocks until the next incoming OSC message
+// Returns nil (no message) once the subscription channel is closed
+func waitForOSC(messages <-chan *osc.Message) tea.Cmd {
+	if messages == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		msg, ok := <-messages
+		if !ok {
+			return nil
+		}
+		return OSCMsg{Message: msg, Messages: messages}
+	}
+}
+
+// trafficMsg carries a sent or received message into the OSC log
+type trafficMsg struct {
+	Traffic adapter.Traffic
+	Source  <-chan adapter.Traffic // channel it arrived on, waited on again
+}
+
+// waitForTraffic returns a command that blocks until the next logged message
+func waitForTraffic(traffic <-chan adapter.Traffic) tea.Cmd {
+	if traffic == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		t, ok := <-traffic
+		if !ok {
+			return nil
+		}
+		return trafficMsg{Traffic: t, Source: traffic}
+	}
+}
+
+// sendErrorMsg carries a failed OSC send into the update loop
+type sendErrorMsg struct {
+	Err    error
+	Errors <-chan error // channel it arrived on, waited on again
+}
+
+// waitForSendError returns a command that blocks until the next failed send
+func waitForSendError(errors <-chan error) tea.Cmd {
+	if errors == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		err, ok := <-errors
+		if !ok {
+			return nil
+		}
+		return sendErrorMsg{Err: err, Errors: errors}
+	}
+}
+
+// tickInterval is how often running automation (morphs etc.) is advanced
+const tickInterval = 50 * time.Millisecond
+
+// tickMsg advances running automation
+type tickMsg time.Time
+
+// tickCmd schedules the next automation tick
+func tickCmd() tea.Cmd {
+	return tea.Tick(tickInterval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// heartbeatMsg carries the outcome of pinging sclang and scsynth
+type heartbeatMsg struct {
+	SClangLatency  time.Duration
+	SClangErr      error
+	ScsynthLatency time.Duration
+	ScsynthErr     error
+	ServerStatus   adapter.ServerStatus
+}
+
+// heartbeatCmd pings sclang and scsynth after a delay
+// The pings block for up to adapter.PingTimeout, off the update loop
+func heartbeatCmd(delay time.Duration, sclang *adapter.OSCAdapter, scsynth *adapter.ScsynthAdapter) tea.Cmd {
+	if sclang == nil {
+		return nil
+	}
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		var msg heartbeatMsg
+		msg.SClangLatency, msg.SClangErr = adapter.PingSClang(sclang, adapter.PingTimeout)
+		if scsynth != nil {
+			msg.ScsynthLatency, msg.ServerStatus, msg.ScsynthErr = scsynth.Ping(adapter.PingTimeout)
+		}
+		return msg
+	})
+}
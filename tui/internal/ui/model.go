@@ -1,8 +1,16 @@
 package tui
 
 import (
+	"slices"
+
 	"forbidden_sequencer/adapter"
+	"forbidden_sequencer/arrangement"
+	"forbidden_sequencer/automation"
+	"forbidden_sequencer/clock"
 	"forbidden_sequencer/controllers"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hypebeast/go-osc/osc"
 )
 
 // Screen represents the current view
@@ -12,29 +20,244 @@ const (
 	ScreenMain Screen = iota
 	ScreenSettings
 	ScreenPatternSelect
+	ScreenPresets
+	ScreenModulation
+	ScreenTransitions
+	ScreenArrangement
+	ScreenFX
 )
 
 // Settings represents persisted application settings
 type Settings struct {
-	SelectedControllerIndex int `json:"selectedControllerIndex"` // index of the selected controller
+	SelectedControllerIndex int     `json:"selectedControllerIndex"` // index of the selected controller
+	BPM                     float64 `json:"bpm"`                     // global tempo (0 uses the default)
+	Subdivision             int     `json:"subdivision"`             // events per beat (0 uses the default)
+	Host                    string  `json:"host"`                    // SuperCollider host ("" uses localhost)
+	Port                    int     `json:"port"`                    // sclang port (0 uses 57120)
+	ServerPort              int     `json:"serverPort"`              // scsynth port (0 uses 57110)
 }
 
 // Model is the main application state
 type Model struct {
-	SClangAdapter *adapter.OSCAdapter // OSC client for sclang (port 57120)
-	Settings      *Settings
-	IsPlaying     bool
-	Screen        Screen
-	Err           error
-	Debug         bool // debug logging enabled
+	SClangAdapter   *adapter.OSCAdapter     // OSC client for sclang (port 57120)
+	SClangMessages  <-chan *osc.Message     // replies received from sclang
+	ScsynthAdapter  *adapter.ScsynthAdapter // OSC client for scsynth (port 57110), nil if unavailable
+	ScsynthMessages <-chan *osc.Message     // replies received from scsynth
+	Settings        *Settings
+	SClangHealth    adapter.Heartbeat    // measured by pinging sclang
+	ScsynthHealth   adapter.Heartbeat    // measured with /status
+	ServerStatus    adapter.ServerStatus // load from the last /status.reply
+	IsPlaying       bool
+	Screen          Screen
+	Err             error
+	Debug           bool // debug logging enabled
 
 	// Pattern controllers
 	AvailableControllers  []controllers.Controller // all available controllers
 	ActiveController      controllers.Controller   // currently active controller instance
 	ActiveControllerIndex int                      // index of active controller
 	SelectedPatternIndex  int                      // temporary selection for pattern screen
+	Layers                []int                    // controllers kept running alongside the active one
+
+	// Global tempo shared by every pattern
+	Clock *clock.Clock
+
+	// Presets screen
+	Presets             []Preset // presets of the active controller
+	SelectedPresetIndex int      // highlighted preset
+	NamingPreset        bool     // typing a name for a new preset
+	PresetNameInput     string   // name typed so far
+
+	// Preset morphing
+	Morph       *automation.Morph     // running morph (nil when idle)
+	MorphSource *Preset               // snapshot A (nil means current values)
+	MorphLength int                   // morph length in MorphUnit
+	MorphUnit   automation.LengthUnit // phrases or seconds
+	MorphInBars bool                  // phrases counted as clock bars, the pattern has no phrase length
+	Ticking     bool                  // an automation tick is scheduled
+
+	// Modulation screen
+	Modulators         []*automation.Modulator // running LFOs/envelopes across all controllers
+	SelectedParamIndex int                     // highlighted parameter
+
+	// Transition matrix screen
+	SelectedVoiceIndex int // voice whose matrix is shown
+	MatrixRow          int // highlighted "from" state
+	MatrixCol          int // highlighted "to" state
+
+	// Solo, exclusive across every pattern
+	Soloing        bool   // a voice is soloed
+	SoloController int    // index of the controller with the soloed voice
+	SoloVoice      string // soloed voice
+
+	// OSC traffic log pane
+	LogEntries       []adapter.Traffic // sent and received messages, newest last
+	ShowLog          bool              // pane shown in the main view
+	LogPaused        bool              // not logging new messages
+	LogMissed        int               // messages not logged while paused
+	LogFilter        string            // address prefix shown ("" shows all)
+	EditingLogFilter bool              // typing a filter
+	LogFilterInput   string            // filter typed so far
+	LogScroll        int               // lines scrolled back from the newest
+
+	// Settings screen
+	SelectedSettingIndex int    // highlighted setting
+	EditingSetting       bool   // typing a new value
+	SettingInput         string // value typed so far
+
+	// Global effects, controlled alongside the active pattern
+	FX controllers.Controller // nil without a scsynth adapter
+
+	// Arrangement (song mode)
+	Conductor *arrangement.Conductor // nil when no arrangement is loaded
 
 	// Window size
 	Width  int
 	Height int
 }
+
+// needsTick reports whether any automation needs periodic ticks
+func (m Model) needsTick() bool {
+	return (m.Morph != nil && !m.Morph.Done()) || len(m.Modulators) > 0 ||
+		(m.Clock != nil && m.Clock.Nudging()) ||
+		(m.Conductor != nil && m.Conductor.Running())
+}
+
+// applyTempo sends the clock's event duration to every pattern
+// Inactive patterns are updated too so switching keeps the groove
+func (m Model) applyTempo() {
+	if m.Clock == nil {
+		return
+	}
+	for _, controller := range m.AvailableControllers {
+		if follower, ok := controller.(controllers.TempoFollower); ok {
+			follower.SetEventDuration(m.Clock.EventDur())
+		}
+	}
+	if m.Settings != nil {
+		m.Settings.BPM = m.Clock.BPM()
+		m.Settings.Subdivision = m.Clock.Subdivision()
+	}
+}
+
+// paramLister is implemented by controllers that expose their parameter schema
+type paramLister interface {
+	Params() []*controllers.Param
+}
+
+// modulatableParams returns the active controller's parameters that can be modulated
+func (m Model) modulatableParams() []*controllers.Param {
+	lister, ok := m.ActiveController.(paramLister)
+	if !ok {
+		return nil
+	}
+	var params []*controllers.Param
+	for _, p := range lister.Params() {
+		if !p.Transient {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// findModulator returns the index of the modulator for a target parameter, or -1
+func (m Model) findModulator(target automation.Target, param string) int {
+	for i, mod := range m.Modulators {
+		if mod.Target() == target && mod.Param() == param {
+			return i
+		}
+	}
+	return -1
+}
+
+// releaseModulators puts a controller's modulated parameters back to their
+// centre values and drops its modulators (when the pattern quits)
+func (m Model) releaseModulators(controller controllers.Controller) Model {
+	var kept []*automation.Modulator
+	for _, mod := range m.Modulators {
+		if target, ok := controller.(automation.Target); ok && mod.Target() == target {
+			mod.Release()
+			continue
+		}
+		kept = append(kept, mod)
+	}
+	m.Modulators = kept
+	return m
+}
+
+// isLayer reports whether a controller keeps running when focus moves away
+func (m Model) isLayer(index int) bool {
+	return slices.Contains(m.Layers, index)
+}
+
+// toggleLayer adds or removes a controller from the layers
+// Returns true if it is now a layer
+func (m Model) toggleLayer(index int) (Model, bool) {
+	if i := slices.Index(m.Layers, index); i >= 0 {
+		m.Layers = slices.Delete(slices.Clone(m.Layers), i, i+1)
+		return m, false
+	}
+	m.Layers = append(slices.Clone(m.Layers), index)
+	slices.Sort(m.Layers)
+	return m, true
+}
+
+// runningControllers returns the active controller and every layer, in list order
+func (m Model) runningControllers() []int {
+	running := slices.Clone(m.Layers)
+	if m.ActiveController != nil && !m.isLayer(m.ActiveControllerIndex) {
+		running = append(running, m.ActiveControllerIndex)
+		slices.Sort(running)
+	}
+	return running
+}
+
+// layerArrangement makes every pattern in the arrangement a layer,
+// so focusing another pattern doesn't reset one the arrangement is playing
+func (m Model) layerArrangement() Model {
+	type identified interface{ ID() string }
+
+	patterns := m.Conductor.Arrangement().Patterns()
+	for i, controller := range m.AvailableControllers {
+		id, ok := controller.(identified)
+		if ok && slices.Contains(patterns, id.ID()) && !m.isLayer(i) {
+			m, _ = m.toggleLayer(i)
+		}
+	}
+	return m.applySolo()
+}
+
+// applySolo tells every running controller which voice is soloed, silencing
+// the rest. Stopped patterns are left alone, and a solo is lifted when the
+// pattern it belongs to stops running
+func (m Model) applySolo() Model {
+	running := m.runningControllers()
+	if m.Soloing && !slices.Contains(running, m.SoloController) {
+		m.Soloing = false
+	}
+
+	for _, i := range running {
+		muter, ok := m.AvailableControllers[i].(controllers.VoiceMuter)
+		if !ok {
+			continue
+		}
+		switch {
+		case !m.Soloing:
+			muter.SetSolo("", false)
+		case i == m.SoloController:
+			muter.SetSolo(m.SoloVoice, true)
+		default:
+			muter.SetSolo("", true)
+		}
+	}
+	return m
+}
+
+// startTicking schedules automation ticks if they aren't already running
+func (m Model) startTicking() (Model, tea.Cmd) {
+	if m.Ticking || !m.needsTick() {
+		return m, nil
+	}
+	m.Ticking = true
+	return m, tickCmd()
+}
@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"forbidden_sequencer/adapter"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// OSC log settings
+const (
+	oscLogSize   = 1000 // entries kept, oldest dropped first
+	oscLogHeight = 10   // lines shown in the pane
+)
+
+// recordTraffic adds a sent or received message to the log
+// While paused, messages are counted but not kept
+func (m Model) recordTraffic(traffic adapter.Traffic) Model {
+	if m.LogPaused {
+		m.LogMissed++
+		return m
+	}
+
+	m.LogEntries = append(m.LogEntries, traffic)
+	if len(m.LogEntries) > oscLogSize {
+		m.LogEntries = m.LogEntries[len(m.LogEntries)-oscLogSize:]
+	}
+
+	// Keep a scrolled-back view on the same lines, until they drop off the top
+	if m.LogScroll > 0 && m.logMatches(traffic) {
+		m.LogScroll = min(m.LogScroll+1, m.maxLogScroll())
+	}
+	return m
+}
+
+// logMatches reports whether an entry passes the address prefix filter
+func (m Model) logMatches(traffic adapter.Traffic) bool {
+	return strings.HasPrefix(traffic.Message.Address, m.LogFilter)
+}
+
+// filteredLog returns the entries passing the filter, oldest first
+func (m Model) filteredLog() []adapter.Traffic {
+	if m.LogFilter == "" {
+		return m.LogEntries
+	}
+	var entries []adapter.Traffic
+	for _, traffic := range m.LogEntries {
+		if m.logMatches(traffic) {
+			entries = append(entries, traffic)
+		}
+	}
+	return entries
+}
+
+// maxLogScroll returns how far back the view can scroll (the top page of the log)
+func (m Model) maxLogScroll() int {
+	return max(0, len(m.filteredLog())-oscLogHeight)
+}
+
+// scrollLog moves the view back (positive) or forward through the log
+func (m Model) scrollLog(lines int) Model {
+	m.LogScroll = max(0, min(m.maxLogScroll(), m.LogScroll+lines))
+	return m
+}
+
+// updateLogFilter handles typing an address prefix filter
+func (m Model) updateLogFilter(msg tea.KeyMsg) Model {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.EditingLogFilter = false
+	case tea.KeyEnter:
+		m.EditingLogFilter = false
+		m.LogFilter = strings.TrimSpace(m.LogFilterInput)
+		m.LogScroll = 0
+	case tea.KeyBackspace:
+		if len(m.LogFilterInput) > 0 {
+			runes := []rune(m.LogFilterInput)
+			m.LogFilterInput = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes:
+		m.LogFilterInput += string(msg.Runes)
+	}
+	return m
+}
+
+// viewLog renders the OSC log pane: a header and the visible lines
+func (m Model) viewLog() string {
+	header := "OSC log"
+	if m.EditingLogFilter {
+		header += fmt.Sprintf(" - filter: %s_", m.LogFilterInput)
+	} else if m.LogFilter != "" {
+		header += " - filter: " + m.LogFilter
+	}
+	if m.LogPaused {
+		header += fmt.Sprintf(" [PAUSED, %d not logged]", m.LogMissed)
+	}
+
+	entries := m.filteredLog()
+	scroll := max(0, min(m.LogScroll, m.maxLogScroll()))
+	end := len(entries) - scroll
+	start := max(0, end-oscLogHeight)
+	if scroll > 0 {
+		header += fmt.Sprintf(" [%d newer]", scroll)
+	}
+
+	lines := []string{HelpStyle.Render(header)}
+	for _, traffic := range entries[start:end] {
+		lines = append(lines, formatTraffic(traffic))
+	}
+	for len(lines) <= oscLogHeight {
+		lines = append(lines, "")
+	}
+
+	style := EventLogStyle
+	if m.Width > 0 {
+		style = style.Width(max(40, m.Width-4))
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// formatTraffic renders a log line: time, direction, address and typed arguments
+func formatTraffic(traffic adapter.Traffic) string {
+	arrow := "→"
+	if traffic.Direction == adapter.Incoming {
+		arrow = "←"
+	}
+	line := fmt.Sprintf("%s %s %s", traffic.Time.Format("15:04:05.000"), arrow, traffic.Message.Address)
+	if args := formatArgs(traffic.Message); args != "" {
+		line += " " + args
+	}
+	return line
+}
+
+// formatArgs renders a message's arguments with their OSC type tags, e.g. "f:0.5 s:kick"
+func formatArgs(msg *osc.Message) string {
+	args := make([]string, len(msg.Arguments))
+	for i, arg := range msg.Arguments {
+		switch v := arg.(type) {
+		case int32:
+			args[i] = fmt.Sprintf("i:%d", v)
+		case int64:
+			args[i] = fmt.Sprintf("h:%d", v)
+		case float32:
+			args[i] = fmt.Sprintf("f:%g", v)
+		case float64:
+			args[i] = fmt.Sprintf("d:%g", v)
+		case string:
+			args[i] = fmt.Sprintf("s:%q", v)
+		case bool:
+			args[i] = "F"
+			if v {
+				args[i] = "T"
+			}
+		case []byte:
+			args[i] = fmt.Sprintf("b:%d bytes", len(v))
+		case nil:
+			args[i] = "N"
+		default:
+			args[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return strings.Join(args, " ")
+}
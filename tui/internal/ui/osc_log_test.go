@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"forbidden_sequencer/adapter"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// traffic returns a logged message for address
+func traffic(address string) adapter.Traffic {
+	return adapter.Traffic{Time: time.Now(), Message: osc.NewMessage(address)}
+}
+
+func TestRecordTrafficKeepsScrollInBoundsWhenFull(t *testing.T) {
+	m := Model{ShowLog: true}
+	for i := 0; i < oscLogSize; i++ {
+		m = m.recordTraffic(traffic(fmt.Sprintf("/pattern/%d", i)))
+	}
+
+	// Scroll to the top, then let the full log keep rotating
+	m = m.scrollLog(oscLogSize)
+	if m.LogScroll != oscLogSize-oscLogHeight {
+		t.Fatalf("LogScroll at top = %d, want %d", m.LogScroll, oscLogSize-oscLogHeight)
+	}
+	for i := 0; i < 50; i++ {
+		m = m.recordTraffic(traffic("/pattern/more"))
+		if m.LogScroll > m.maxLogScroll() {
+			t.Fatalf("after %d more entries LogScroll = %d, beyond %d", i+1, m.LogScroll, m.maxLogScroll())
+		}
+		m.viewLog() // must not panic
+	}
+	if len(m.LogEntries) != oscLogSize {
+		t.Errorf("len(LogEntries) = %d, want %d", len(m.LogEntries), oscLogSize)
+	}
+}
+
+func TestViewLogClampsStaleScroll(t *testing.T) {
+	m := Model{ShowLog: true}
+	for i := 0; i < 20; i++ {
+		m = m.recordTraffic(traffic("/pattern/a"))
+	}
+	m.LogScroll = 500 // e.g. left over from before a filter narrowed the log
+	m.LogFilter = "/pattern"
+	m.viewLog()
+
+	m.LogFilter = "/nothing"
+	m.viewLog()
+}
+
+func TestRecordTrafficFollowsFilteredScroll(t *testing.T) {
+	m := Model{LogFilter: "/pattern"}
+	for i := 0; i < 30; i++ {
+		m = m.recordTraffic(traffic("/pattern/a"))
+	}
+	m = m.scrollLog(5)
+
+	m = m.recordTraffic(traffic("/status.reply"))
+	if m.LogScroll != 5 {
+		t.Errorf("filtered-out entry moved the view: LogScroll = %d, want 5", m.LogScroll)
+	}
+	m = m.recordTraffic(traffic("/pattern/b"))
+	if m.LogScroll != 6 {
+		t.Errorf("matching entry didn't hold the view: LogScroll = %d, want 6", m.LogScroll)
+	}
+}
@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+)
+
+// Preset is a named snapshot of a controller's parameters
+type Preset struct {
+	Name   string             `json:"name"`
+	Values map[string]float64 `json:"values"` // parameter name -> value
+}
+
+// getPresetsPath returns the path to a controller's presets file
+// Presets live next to settings.json, one file per controller
+func getPresetsPath(controllerID string) (string, error) {
+	return xdg.ConfigFile(fmt.Sprintf("forbidden_sequencer/presets/%s.json", controllerID))
+}
+
+// LoadPresets loads a controller's presets, returns none if the file doesn't exist
+func LoadPresets(controllerID string) ([]Preset, error) {
+	presetsPath, err := getPresetsPath(controllerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// No presets saved yet
+	if _, err := os.Stat(presetsPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(presetsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets file: %w", err)
+	}
+
+	var presets []Preset
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("failed to parse presets: %w", err)
+	}
+
+	return presets, nil
+}
+
+// SavePresets saves a controller's presets to disk
+func SavePresets(controllerID string, presets []Preset) error {
+	presetsPath, err := getPresetsPath(controllerID)
+	if err != nil {
+		return err
+	}
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(presetsPath), 0755); err != nil {
+		return fmt.Errorf("failed to create presets directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal presets: %w", err)
+	}
+
+	if err := os.WriteFile(presetsPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write presets file: %w", err)
+	}
+
+	return nil
+}
+
+// FindPreset returns the preset with the given name
+func FindPreset(presets []Preset, name string) (Preset, bool) {
+	for _, p := range presets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// LookupPreset returns the values of a controller's named preset
+// Used by the arrangement conductor to restore presets by name
+func LookupPreset(controllerID, name string) (map[string]float64, error) {
+	presets, err := LoadPresets(controllerID)
+	if err != nil {
+		return nil, err
+	}
+	preset, ok := FindPreset(presets, name)
+	if !ok {
+		return nil, fmt.Errorf("no preset named %q", name)
+	}
+	return preset.Values, nil
+}
@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"forbidden_sequencer/adapter"
 
 	"github.com/adrg/xdg"
 )
@@ -14,6 +18,51 @@ func getSettingsPath() (string, error) {
 	return xdg.ConfigFile("forbidden_sequencer/settings.json")
 }
 
+// DefaultArrangementPath returns where the arrangement is read from without --arrangement
+func DefaultArrangementPath() (string, error) {
+	return xdg.ConfigFile("forbidden_sequencer/arrangement.yaml")
+}
+
+// DefaultPatternsDir returns where pattern manifests are read from without --patterns:
+// Supercollider/patterns next to the checkout the binary was built in (tui/../),
+// or else forbidden_sequencer/patterns in the config directory
+// It doesn't depend on the working directory
+func DefaultPatternsDir() string {
+	if exe, err := os.Executable(); err == nil {
+		if exe, err = filepath.EvalSymlinks(exe); err == nil {
+			dir := filepath.Join(filepath.Dir(exe), "..", "Supercollider", "patterns")
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				return dir
+			}
+		}
+	}
+	return filepath.Join(xdg.ConfigHome, "forbidden_sequencer", "patterns")
+}
+
+// Target returns the saved SuperCollider host and ports, with defaults for unset ones
+func (s *Settings) Target() (host string, sclangPort, scsynthPort int) {
+	host, sclangPort, scsynthPort = adapter.DefaultHost, adapter.DefaultSClangPort, adapter.DefaultScsynthPort
+	if s.Host != "" {
+		host = s.Host
+	}
+	if s.Port != 0 {
+		sclangPort = s.Port
+	}
+	if s.ServerPort != 0 {
+		scsynthPort = s.ServerPort
+	}
+	return host, sclangPort, scsynthPort
+}
+
+// ParsePort parses a UDP port number
+func ParsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q: must be 1-65535", s)
+	}
+	return port, nil
+}
+
 // LoadSettings loads settings from disk, returns defaults if file doesn't exist
 func LoadSettings() (*Settings, error) {
 	settingsPath, err := getSettingsPath()
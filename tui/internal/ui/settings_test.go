@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"path/filepath"
+	"testing"
+
+	"forbidden_sequencer/adapter"
+
+	"github.com/adrg/xdg"
+)
+
+// useTempConfig points the settings file at a temporary directory
+func useTempConfig(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+}
+
+func TestApplySettingSavesOnlyEditedField(t *testing.T) {
+	useTempConfig(t)
+
+	// The adapters run against a target overridden by --host and --port
+	sclangAdapter, err := adapter.NewOSCAdapter("127.0.0.2", 57999)
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+	defer sclangAdapter.Close()
+	scsynthAdapter, err := adapter.NewScsynthAdapter("127.0.0.2", adapter.DefaultScsynthPort)
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+	defer scsynthAdapter.Close()
+
+	m := Model{
+		SClangAdapter:  sclangAdapter,
+		ScsynthAdapter: scsynthAdapter,
+		Settings:       &Settings{BPM: 100},
+	}
+	m = m.applySetting(settingScsynthPort, "57111")
+	if m.Err != nil {
+		t.Fatalf("applySetting failed: %v", m.Err)
+	}
+	if got := scsynthAdapter.GetPort(); got != 57111 {
+		t.Errorf("scsynth port = %d, want 57111", got)
+	}
+
+	saved, err := LoadSettings()
+	if err != nil {
+		t.Fatalf("failed to load saved settings: %v", err)
+	}
+	want := Settings{BPM: 100, ServerPort: 57111}
+	if *saved != want {
+		t.Errorf("saved %+v, want %+v (overrides must not be saved)", *saved, want)
+	}
+}
+
+func TestApplySettingRejectsInvalidPort(t *testing.T) {
+	useTempConfig(t)
+
+	sclangAdapter, err := adapter.NewOSCAdapter("localhost", adapter.DefaultSClangPort)
+	if err != nil {
+		t.Fatalf("failed to create adapter: %v", err)
+	}
+	defer sclangAdapter.Close()
+
+	m := Model{SClangAdapter: sclangAdapter, Settings: &Settings{}}
+	m = m.applySetting(settingSClangPort, "70000")
+	if m.Err == nil {
+		t.Error("port 70000 was accepted")
+	}
+	if got := sclangAdapter.GetPort(); got != adapter.DefaultSClangPort {
+		t.Errorf("sclang port = %d after a rejected edit, want %d", got, adapter.DefaultSClangPort)
+	}
+}
+
+func TestDefaultPatternsDirFallsBackToConfig(t *testing.T) {
+	useTempConfig(t)
+
+	// The test binary isn't next to a checkout, so the config directory is used
+	want := filepath.Join(xdg.ConfigHome, "forbidden_sequencer", "patterns")
+	if got := DefaultPatternsDir(); got != want {
+		t.Errorf("DefaultPatternsDir = %s, want %s", got, want)
+	}
+}
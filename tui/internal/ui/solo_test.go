@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"testing"
+
+	"forbidden_sequencer/controllers"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeMuter is a pattern with kick and snare voices that records solo calls
+type fakeMuter struct {
+	active  string
+	solo    string
+	soloing bool
+	calls   int
+}
+
+func (f *fakeMuter) GetName() string                { return "fake" }
+func (f *fakeMuter) GetKeybindings() string         { return "" }
+func (f *fakeMuter) GetStatus() string              { return "" }
+func (f *fakeMuter) HandleInput(tea.KeyMsg) bool    { return false }
+func (f *fakeMuter) Quit()                          { f.solo, f.soloing = "", false }
+func (f *fakeMuter) VoiceMuted(string) bool         { return false }
+func (f *fakeMuter) SetVoiceMuted(string, bool)     {}
+func (f *fakeMuter) ActiveVoice() controllers.Voice { return controllers.Voice{Name: f.active} }
+func (f *fakeMuter) Voices() []controllers.Voice {
+	return []controllers.Voice{{Name: "kick"}, {Name: "snare"}}
+}
+func (f *fakeMuter) SetSolo(solo string, active bool) {
+	f.solo, f.soloing = solo, active
+	f.calls++
+}
+
+// soloModel runs the first two of three patterns as layers, focusing the
+// first; the third isn't running
+func soloModel() (Model, []*fakeMuter) {
+	muters := []*fakeMuter{{active: "kick"}, {active: "snare"}, {active: "kick"}}
+	m := Model{Screen: ScreenMain, Layers: []int{0, 1}}
+	for _, muter := range muters {
+		m.AvailableControllers = append(m.AvailableControllers, muter)
+	}
+	m.ActiveController = m.AvailableControllers[0]
+	return m, muters
+}
+
+// focus makes a running pattern the active one
+func focus(m Model, index int) Model {
+	m.ActiveControllerIndex = index
+	m.ActiveController = m.AvailableControllers[index]
+	return m
+}
+
+// press sends a key to the current screen
+func press(t *testing.T, m Model, key string) Model {
+	t.Helper()
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return updated.(Model)
+}
+
+func TestSoloIsExclusive(t *testing.T) {
+	tests := []struct {
+		name    string
+		focus   int    // pattern soloed last
+		solo    string // voice it solos
+		silence int    // running pattern it silences
+	}{
+		{"active pattern", 0, "kick", 1},
+		{"layer", 1, "snare", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, muters := soloModel()
+
+			// Solo in the other running pattern first; the new solo replaces it
+			m = press(t, focus(m, tt.silence), "M")
+			m = press(t, focus(m, tt.focus), "M")
+
+			if !m.Soloing || m.SoloController != tt.focus || m.SoloVoice != tt.solo {
+				t.Fatalf("solo = %v %d %q, want %d %q", m.Soloing, m.SoloController, m.SoloVoice, tt.focus, tt.solo)
+			}
+			if got := muters[tt.focus]; !got.soloing || got.solo != tt.solo {
+				t.Errorf("soloed pattern: SetSolo(%q, %v), want (%q, true)", got.solo, got.soloing, tt.solo)
+			}
+			if got := muters[tt.silence]; !got.soloing || got.solo != "" {
+				t.Errorf("other pattern: SetSolo(%q, %v), want silenced", got.solo, got.soloing)
+			}
+			if got := muters[2].calls; got != 0 {
+				t.Errorf("stopped pattern got %d SetSolo calls, want none", got)
+			}
+		})
+	}
+}
+
+func TestSoloClears(t *testing.T) {
+	m, muters := soloModel()
+
+	// The same key on the same voice lifts the solo
+	m = press(t, m, "M")
+	m = press(t, m, "M")
+
+	if m.Soloing {
+		t.Error("still soloing after pressing M twice")
+	}
+	for i, muter := range muters[:2] {
+		if muter.soloing {
+			t.Errorf("pattern %d still silenced by solo %q", i, muter.solo)
+		}
+	}
+	if got := muters[2].calls; got != 0 {
+		t.Errorf("stopped pattern got %d SetSolo calls, want none", got)
+	}
+}
+
+func TestSoloLiftedWhenItsPatternStops(t *testing.T) {
+	m, muters := soloModel()
+	m = press(t, focus(m, 1), "M")
+	m = focus(m, 0)
+
+	// Remove the soloing layer in the pattern list, which quits it
+	m.Screen, m.SelectedPatternIndex = ScreenPatternSelect, 1
+	m = press(t, m, "l")
+
+	if m.Soloing {
+		t.Error("solo kept after its pattern stopped")
+	}
+	if muters[0].soloing {
+		t.Error("active pattern still silenced by a stopped pattern's solo")
+	}
+	if got := muters[2].calls; got != 0 {
+		t.Errorf("stopped pattern got %d SetSolo calls, want none", got)
+	}
+}
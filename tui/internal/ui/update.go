@@ -1,12 +1,45 @@
 package tui
 
 import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"forbidden_sequencer/adapter"
+	"forbidden_sequencer/automation"
+	"forbidden_sequencer/controllers"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // Init implements tea.Model
 func (m Model) Init() tea.Cmd {
-	return nil
+	// Start every pattern at the global tempo
+	m.applyTempo()
+
+	// Ask every pattern for its state so controllers start in sync with sclang
+	for _, controller := range m.AvailableControllers {
+		if syncer, ok := controller.(controllers.Syncer); ok {
+			syncer.Query()
+		}
+	}
+	if syncer, ok := m.FX.(controllers.Syncer); ok {
+		syncer.Query()
+	}
+	cmds := []tea.Cmd{
+		waitForOSC(m.SClangMessages),
+		waitForOSC(m.ScsynthMessages),
+		heartbeatCmd(0, m.SClangAdapter, m.ScsynthAdapter),
+	}
+	if m.SClangAdapter != nil {
+		cmds = append(cmds, waitForSendError(m.SClangAdapter.Errors()), waitForTraffic(m.SClangAdapter.Traffic()))
+	}
+	if m.ScsynthAdapter != nil {
+		cmds = append(cmds, waitForSendError(m.ScsynthAdapter.Errors()), waitForTraffic(m.ScsynthAdapter.Traffic()))
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update implements tea.Model
@@ -17,28 +50,69 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.Height = msg.Height
 		return m, nil
 
-	case tea.KeyMsg:
-		// Global keys
-		switch msg.String() {
-		case "ctrl+c", "q", "esc":
-			if m.ActiveController != nil {
-				m.ActiveController.Quit()
+	case OSCMsg:
+		// Route replies to every controller, not just the active one
+		for _, controller := range m.AvailableControllers {
+			if receiver, ok := controller.(controllers.OSCReceiver); ok {
+				receiver.HandleOSC(msg.Message)
 			}
-			// Save settings before quitting
-			if m.Settings != nil {
-				m.Settings.SelectedControllerIndex = m.ActiveControllerIndex
-				SaveSettings(m.Settings)
+		}
+		if receiver, ok := m.FX.(controllers.OSCReceiver); ok {
+			receiver.HandleOSC(msg.Message)
+		}
+		return m, waitForOSC(msg.Messages)
+
+	case heartbeatMsg:
+		// Update connection health and schedule the next ping
+		m.SClangHealth.Record(msg.SClangLatency, msg.SClangErr)
+		if m.ScsynthAdapter != nil {
+			m.ScsynthHealth.Record(msg.ScsynthLatency, msg.ScsynthErr)
+			if msg.ScsynthErr == nil {
+				m.ServerStatus = msg.ServerStatus
 			}
-			return m, tea.Quit
+		}
+		m = m.retrySends()
+		return m, heartbeatCmd(adapter.HeartbeatInterval, m.SClangAdapter, m.ScsynthAdapter)
+
+	case trafficMsg:
+		m = m.recordTraffic(msg.Traffic)
+		return m, waitForTraffic(msg.Source)
+
+	case sendErrorMsg:
+		// Show the failure; parameter values are retried on the next heartbeat
+		m.Err = msg.Err
+		return m, waitForSendError(msg.Errors)
 
-		case "tab":
-			// Show pattern selection screen
-			if len(m.AvailableControllers) > 1 {
-				m.SelectedPatternIndex = m.ActiveControllerIndex
-				m.Screen = ScreenPatternSelect
+	case tickMsg:
+		// Advance running automation
+		now := time.Time(msg)
+		if m.Morph != nil {
+			m.Morph.Tick(now)
+			if m.Morph.Done() {
+				m.Morph = nil
 			}
+		}
+		for _, mod := range m.Modulators {
+			mod.Tick(now)
+		}
+		if m.Clock != nil && m.Clock.Tick(now) {
+			// A nudge ended - back to the set tempo
+			m.applyTempo()
+		}
+		if m.Conductor != nil && m.Conductor.Tick(now) && m.Conductor.Err() != nil {
+			m.Err = m.Conductor.Err()
+		}
+		if !m.needsTick() {
+			m.Ticking = false
 			return m, nil
 		}
+		return m, tickCmd()
+
+	case tea.KeyMsg:
+		// ctrl+c always quits, other keys depend on the screen
+		if msg.String() == "ctrl+c" {
+			return m.quit()
+		}
 
 		// Screen-specific keys
 		switch m.Screen {
@@ -48,13 +122,89 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateSettings(msg)
 		case ScreenPatternSelect:
 			return m.updatePatternSelect(msg)
+		case ScreenPresets:
+			return m.updatePresets(msg)
+		case ScreenModulation:
+			return m.updateModulation(msg)
+		case ScreenTransitions:
+			return m.updateTransitions(msg)
+		case ScreenArrangement:
+			return m.updateArrangement(msg)
+		case ScreenFX:
+			return m.updateFX(msg)
 		}
 	}
 
 	return m, nil
 }
 
+// retrySends re-sends parameter and effect values whose send failed
+// Once everything has gone through, a send error is cleared from the error area
+func (m Model) retrySends() Model {
+	var adapters []*adapter.OSCAdapter
+	if m.SClangAdapter != nil {
+		adapters = append(adapters, m.SClangAdapter)
+	}
+	if m.ScsynthAdapter != nil {
+		adapters = append(adapters, m.ScsynthAdapter.OSCAdapter)
+	}
+
+	retried := false
+	for _, a := range adapters {
+		if a.Pending() == 0 {
+			continue
+		}
+		retried = true
+		if err := a.RetryPending(); err != nil {
+			return m
+		}
+	}
+	if !retried {
+		return m
+	}
+	var sendErr *adapter.SendError
+	if errors.As(m.Err, &sendErr) {
+		m.Err = nil
+	}
+	return m
+}
+
+// quit stops the active controller and every layer, saves settings and exits
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	if m.Conductor != nil && m.Conductor.Running() {
+		m.Conductor.Stop()
+	}
+	for _, index := range m.runningControllers() {
+		m = m.releaseModulators(m.AvailableControllers[index])
+		m.AvailableControllers[index].Quit()
+	}
+	// Save settings before quitting
+	if m.Settings != nil {
+		m.Settings.SelectedControllerIndex = m.ActiveControllerIndex
+		SaveSettings(m.Settings)
+	}
+	return m, tea.Quit
+}
+
 func (m Model) updateMain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Typing an OSC log filter
+	if m.EditingLogFilter {
+		return m.updateLogFilter(msg), nil
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		return m.quit()
+
+	case "tab":
+		// Show pattern selection screen
+		if len(m.AvailableControllers) > 1 {
+			m.SelectedPatternIndex = m.ActiveControllerIndex
+			m.Screen = ScreenPatternSelect
+		}
+		return m, nil
+	}
+
 	// Try controller-specific input first
 	if m.ActiveController != nil {
 		if m.ActiveController.HandleInput(msg) {
@@ -62,20 +212,321 @@ func (m Model) updateMain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	// Global keys (none currently, controller handles play/pause)
+	// Global keys (controller handles play/pause)
+	switch msg.String() {
+	case "ctrl+r":
+		// Resync: adopt sclang's state, or push ours if it was lost
+		if syncer, ok := m.ActiveController.(controllers.Syncer); ok {
+			syncer.Query()
+		}
+		return m, nil
+
+	case "ctrl+p":
+		// Force sclang to match the controller
+		if syncer, ok := m.ActiveController.(controllers.Syncer); ok {
+			syncer.PushAll()
+		}
+		return m, nil
+
+	case "ctrl+x":
+		// Abort a running morph, leaving parameters where they are
+		if m.Morph != nil {
+			m.Morph.Abort()
+			m.Morph = nil
+		}
+		return m, nil
+
+	case "t":
+		// Tap tempo
+		if m.Clock != nil && m.Clock.Tap(time.Now()) {
+			m.applyTempo()
+		}
+		return m, nil
+
+	case "-", "=":
+		// Tempo down/up by one BPM
+		if m.Clock != nil {
+			delta := 1.0
+			if msg.String() == "-" {
+				delta = -1
+			}
+			m.Clock.SetBPM(m.Clock.BPM() + delta)
+			m.applyTempo()
+		}
+		return m, nil
+
+	case "<", ">":
+		// Nudge the groove slower/faster for a moment
+		if m.Clock != nil {
+			direction := 1
+			if msg.String() == "<" {
+				direction = -1
+			}
+			m.Clock.Nudge(direction, time.Now())
+			m.applyTempo()
+			return m.startTicking()
+		}
+		return m, nil
+
+	case "T":
+		// Cycle the subdivision (events per beat)
+		if m.Clock != nil {
+			m.Clock.NextSubdivision()
+			m.applyTempo()
+		}
+		return m, nil
+
+	case "X":
+		// Show transition matrices for controllers with Markov voices
+		if editor, ok := m.ActiveController.(controllers.TransitionEditor); ok && len(editor.Voices()) > 0 {
+			m.SelectedVoiceIndex, m.MatrixRow, m.MatrixCol = 0, 0, 0
+			editor.QueryMatrix(editor.Voices()[0].Name)
+			m.Screen = ScreenTransitions
+		}
+		return m, nil
+
+	case "M":
+		// Solo the active voice across every pattern, or lift the solo
+		muter, ok := m.ActiveController.(controllers.VoiceMuter)
+		if !ok || len(muter.Voices()) == 0 {
+			return m, nil
+		}
+		voice := muter.ActiveVoice().Name
+		if m.Soloing && m.SoloController == m.ActiveControllerIndex && m.SoloVoice == voice {
+			m.Soloing = false
+		} else {
+			m.Soloing, m.SoloController, m.SoloVoice = true, m.ActiveControllerIndex, voice
+		}
+		return m.applySolo(), nil
+
+	case "ctrl+a":
+		// Show the arrangement (song mode)
+		if m.Conductor != nil {
+			m.Screen = ScreenArrangement
+		}
+		return m, nil
+
+	case "ctrl+l":
+		// Show/hide the OSC log
+		m.ShowLog = !m.ShowLog
+		return m, nil
+
+	case "ctrl+g":
+		// Filter the OSC log by address prefix
+		if m.ShowLog {
+			m.EditingLogFilter = true
+			m.LogFilterInput = m.LogFilter
+		}
+		return m, nil
+
+	case "ctrl+e":
+		// Pause/resume logging
+		if m.ShowLog {
+			m.LogPaused = !m.LogPaused
+			m.LogMissed = 0
+		}
+		return m, nil
+
+	case "pgup", "pgdown", "end":
+		// Scroll the OSC log (end follows the newest)
+		if m.ShowLog {
+			switch msg.String() {
+			case "pgup":
+				m = m.scrollLog(oscLogHeight)
+			case "pgdown":
+				m = m.scrollLog(-oscLogHeight)
+			default:
+				m.LogScroll = 0
+			}
+		}
+		return m, nil
+
+	case "ctrl+o":
+		// Show settings (SuperCollider host and ports)
+		m.SelectedSettingIndex = 0
+		m.EditingSetting = false
+		m.Screen = ScreenSettings
+		return m, nil
+
+	case "ctrl+f":
+		// Show the global effects, keeping the active pattern
+		if m.FX != nil {
+			m.Screen = ScreenFX
+		}
+		return m, nil
+
+	case "L":
+		// Show modulation (LFO) assignments for the active controller
+		if _, ok := m.ActiveController.(automation.Target); ok {
+			m.SelectedParamIndex = 0
+			m.Screen = ScreenModulation
+		}
+		return m, nil
+
+	case "P":
+		// Show presets for the active controller
+		presettable, ok := m.ActiveController.(controllers.Presettable)
+		if !ok {
+			return m, nil
+		}
+		presets, err := LoadPresets(presettable.ID())
+		if err != nil {
+			m.Err = err
+			return m, nil
+		}
+		m.Presets = presets
+		m.SelectedPresetIndex = 0
+		m.NamingPreset = false
+		m.Screen = ScreenPresets
+		return m, nil
+	}
 
 	return m, nil
 }
 
+// Editable settings, in display order
+const (
+	settingHost = iota
+	settingSClangPort
+	settingScsynthPort
+	settingCount
+)
+
 func (m Model) updateSettings(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Typing a new value
+	if m.EditingSetting {
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.EditingSetting = false
+		case tea.KeyEnter:
+			m.EditingSetting = false
+			m = m.applySetting(m.SelectedSettingIndex, m.SettingInput)
+		case tea.KeyBackspace:
+			if len(m.SettingInput) > 0 {
+				runes := []rune(m.SettingInput)
+				m.SettingInput = string(runes[:len(runes)-1])
+			}
+		case tea.KeyRunes:
+			m.SettingInput += string(msg.Runes)
+		}
+		return m, nil
+	}
+
 	switch msg.String() {
 	case "q", "esc":
 		m.Screen = ScreenMain
+
+	case "up", "k":
+		if m.SelectedSettingIndex > 0 {
+			m.SelectedSettingIndex--
+		}
+
+	case "down", "j":
+		last := settingCount - 1
+		if m.ScsynthAdapter == nil {
+			last = settingScsynthPort - 1
+		}
+		if m.SelectedSettingIndex < last {
+			m.SelectedSettingIndex++
+		}
+
+	case "enter":
+		// Edit the highlighted setting, starting from its current value
+		if m.SClangAdapter != nil {
+			m.EditingSetting = true
+			m.SettingInput = m.settingValue(m.SelectedSettingIndex)
+		}
 	}
 
 	return m, nil
 }
 
+// settingValue returns the current value of an editable setting
+func (m Model) settingValue(index int) string {
+	switch index {
+	case settingHost:
+		return m.SClangAdapter.GetHost()
+	case settingSClangPort:
+		return strconv.Itoa(m.SClangAdapter.GetPort())
+	case settingScsynthPort:
+		if m.ScsynthAdapter != nil {
+			return strconv.Itoa(m.ScsynthAdapter.GetPort())
+		}
+	}
+	return ""
+}
+
+// applySetting retargets the adapters to an edited host or port and saves it
+// Only the edited field is saved: the others may come from flags or the
+// environment, which apply to this run only
+// Patterns and effects are queried again so they pick up the new instance's state
+func (m Model) applySetting(index int, input string) Model {
+	input = strings.TrimSpace(input)
+	host := m.SClangAdapter.GetHost()
+	sclangPort := m.SClangAdapter.GetPort()
+	scsynthPort := 0
+	if m.ScsynthAdapter != nil {
+		scsynthPort = m.ScsynthAdapter.GetPort()
+	}
+
+	var err error
+	switch index {
+	case settingHost:
+		if input == "" {
+			m.Err = errors.New("host can't be empty")
+			return m
+		}
+		host = input
+	case settingSClangPort:
+		sclangPort, err = ParsePort(input)
+	case settingScsynthPort:
+		scsynthPort, err = ParsePort(input)
+	}
+	if err != nil {
+		m.Err = err
+		return m
+	}
+
+	if err := m.SClangAdapter.SetTarget(host, sclangPort); err != nil {
+		m.Err = err
+		return m
+	}
+	if m.ScsynthAdapter != nil {
+		if err := m.ScsynthAdapter.SetTarget(host, scsynthPort); err != nil {
+			m.Err = err
+			return m
+		}
+	}
+	m.Err = nil
+
+	// Health is measured afresh against the new target
+	m.SClangHealth = adapter.Heartbeat{}
+	m.ScsynthHealth = adapter.Heartbeat{}
+	for _, controller := range m.AvailableControllers {
+		if syncer, ok := controller.(controllers.Syncer); ok {
+			syncer.Query()
+		}
+	}
+	if syncer, ok := m.FX.(controllers.Syncer); ok {
+		syncer.Query()
+	}
+
+	if m.Settings != nil {
+		switch index {
+		case settingHost:
+			m.Settings.Host = host
+		case settingSClangPort:
+			m.Settings.Port = sclangPort
+		case settingScsynthPort:
+			m.Settings.ServerPort = scsynthPort
+		}
+		if err := SaveSettings(m.Settings); err != nil {
+			m.Err = err
+		}
+	}
+	return m
+}
+
 func (m Model) updatePatternSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
@@ -97,11 +548,47 @@ func (m Model) updatePatternSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case "l":
+		// Toggle the highlighted pattern as a layer
+		var added bool
+		m, added = m.toggleLayer(m.SelectedPatternIndex)
+		if !added && m.SelectedPatternIndex != m.ActiveControllerIndex {
+			// No longer running in the background
+			m = m.releaseModulators(m.AvailableControllers[m.SelectedPatternIndex])
+			m.AvailableControllers[m.SelectedPatternIndex].Quit()
+		}
+		return m.applySolo(), nil
+
+	case " ":
+		// Play/stop the highlighted layer without focusing it
+		if !m.isLayer(m.SelectedPatternIndex) && m.SelectedPatternIndex != m.ActiveControllerIndex {
+			return m, nil
+		}
+		if layer, ok := m.AvailableControllers[m.SelectedPatternIndex].(controllers.Layerable); ok {
+			if layer.IsPlaying() {
+				layer.Stop()
+			} else {
+				layer.Play()
+			}
+		}
+		return m, nil
+
+	case "m":
+		// Mute/unmute the highlighted layer
+		if !m.isLayer(m.SelectedPatternIndex) && m.SelectedPatternIndex != m.ActiveControllerIndex {
+			return m, nil
+		}
+		if layer, ok := m.AvailableControllers[m.SelectedPatternIndex].(controllers.Layerable); ok {
+			layer.SetMuted(!layer.Muted())
+		}
+		return m, nil
+
 	case "enter":
 		// Select pattern
 		if m.SelectedPatternIndex != m.ActiveControllerIndex {
-			// Quit the old controller
-			if m.ActiveController != nil {
+			// Quit the old controller unless it keeps running as a layer
+			if m.ActiveController != nil && !m.isLayer(m.ActiveControllerIndex) {
+				m = m.releaseModulators(m.ActiveController)
 				m.ActiveController.Quit()
 			}
 
@@ -109,6 +596,12 @@ func (m Model) updatePatternSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.ActiveControllerIndex = m.SelectedPatternIndex
 			m.ActiveController = m.AvailableControllers[m.ActiveControllerIndex]
 
+			// Pick up whatever state sclang has for the new pattern
+			if syncer, ok := m.ActiveController.(controllers.Syncer); ok {
+				syncer.Query()
+			}
+			m = m.applySolo()
+
 			// Save settings immediately after switching
 			if m.Settings != nil {
 				m.Settings.SelectedControllerIndex = m.ActiveControllerIndex
@@ -124,3 +617,375 @@ func (m Model) updatePatternSelect(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m Model) updatePresets(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	presettable, ok := m.ActiveController.(controllers.Presettable)
+	if !ok {
+		m.Screen = ScreenMain
+		return m, nil
+	}
+
+	// Typing a name for a new preset
+	if m.NamingPreset {
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.NamingPreset = false
+		case tea.KeyEnter:
+			if m.PresetNameInput != "" {
+				m.NamingPreset = false
+				m = m.storePreset(presettable, m.PresetNameInput)
+			}
+		case tea.KeyBackspace:
+			if len(m.PresetNameInput) > 0 {
+				runes := []rune(m.PresetNameInput)
+				m.PresetNameInput = string(runes[:len(runes)-1])
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			m.PresetNameInput += string(msg.Runes)
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.Screen = ScreenMain
+
+	case "up", "k":
+		if m.SelectedPresetIndex > 0 {
+			m.SelectedPresetIndex--
+		}
+
+	case "down", "j":
+		if m.SelectedPresetIndex < len(m.Presets)-1 {
+			m.SelectedPresetIndex++
+		}
+
+	case "enter":
+		// Recall - push every value to sclang
+		if m.SelectedPresetIndex < len(m.Presets) {
+			presettable.Restore(m.Presets[m.SelectedPresetIndex].Values)
+			m.Screen = ScreenMain
+		}
+
+	case "s":
+		// Save current values under a new name
+		m.NamingPreset = true
+		m.PresetNameInput = ""
+
+	case "o":
+		// Overwrite the selected preset with current values
+		if m.SelectedPresetIndex < len(m.Presets) {
+			m = m.storePreset(presettable, m.Presets[m.SelectedPresetIndex].Name)
+		}
+
+	case "a":
+		// Mark the selected preset as morph source A (again to use current values)
+		if m.SelectedPresetIndex < len(m.Presets) {
+			selected := m.Presets[m.SelectedPresetIndex]
+			if m.MorphSource != nil && m.MorphSource.Name == selected.Name {
+				m.MorphSource = nil
+			} else {
+				m.MorphSource = &selected
+			}
+		}
+
+	case "m":
+		// Morph from A to the selected preset
+		target, ok := m.ActiveController.(automation.Target)
+		if !ok || m.SelectedPresetIndex >= len(m.Presets) {
+			return m, nil
+		}
+		from := presettable.Snapshot()
+		if m.MorphSource != nil {
+			from = m.MorphSource.Values
+		}
+		to := m.Presets[m.SelectedPresetIndex].Values
+		morph, err := automation.NewMorph(target, from, to, float64(m.MorphLength), m.MorphUnit)
+		m.MorphInBars = false
+		if errors.Is(err, automation.ErrNoPhraseLength) && m.Clock != nil {
+			// Count bars at the global tempo instead, and say so in the morph status
+			seconds := float64(m.MorphLength) * m.Clock.BarDuration()
+			morph, err = automation.NewMorph(target, from, to, seconds, automation.Seconds)
+			m.MorphInBars = true
+		}
+		if err != nil {
+			m.Err = err
+			return m, nil
+		}
+		m.Morph = morph
+		m.Screen = ScreenMain
+		return m.startTicking()
+
+	case "[":
+		// Shorter morph
+		if m.MorphLength > 1 {
+			m.MorphLength--
+		}
+
+	case "]":
+		// Longer morph
+		if m.MorphLength < 64 {
+			m.MorphLength++
+		}
+
+	case "u":
+		// Switch morph length between phrases and seconds
+		if m.MorphUnit == automation.Phrases {
+			m.MorphUnit = automation.Seconds
+		} else {
+			m.MorphUnit = automation.Phrases
+		}
+
+	case " ":
+		// Pause/resume a running morph
+		if m.Morph != nil {
+			if m.Morph.Paused() {
+				m.Morph.Resume()
+			} else {
+				m.Morph.Pause()
+			}
+		}
+
+	case "x":
+		// Abort a running morph
+		if m.Morph != nil {
+			m.Morph.Abort()
+			m.Morph = nil
+		}
+
+	case "d":
+		// Delete the selected preset
+		if m.SelectedPresetIndex < len(m.Presets) {
+			m.Presets = append(m.Presets[:m.SelectedPresetIndex:m.SelectedPresetIndex], m.Presets[m.SelectedPresetIndex+1:]...)
+			if m.SelectedPresetIndex > 0 && m.SelectedPresetIndex >= len(m.Presets) {
+				m.SelectedPresetIndex--
+			}
+			if err := SavePresets(presettable.ID(), m.Presets); err != nil {
+				m.Err = err
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// storePreset saves the controller's current values under name, replacing
+// any preset with the same name, and selects it
+// Modulated parameters are saved at their centre, not wherever the LFO is
+func (m Model) storePreset(presettable controllers.Presettable, name string) Model {
+	values := presettable.Snapshot()
+	for _, mod := range m.Modulators {
+		if target, ok := presettable.(automation.Target); ok && mod.Target() == target {
+			if _, saved := values[mod.Param()]; saved {
+				values[mod.Param()] = mod.Center()
+			}
+		}
+	}
+	preset := Preset{Name: name, Values: values}
+
+	presets := append([]Preset(nil), m.Presets...)
+	index := -1
+	for i, p := range presets {
+		if p.Name == name {
+			index = i
+			presets[i] = preset
+		}
+	}
+	if index < 0 {
+		presets = append(presets, preset)
+		index = len(presets) - 1
+	}
+
+	if err := SavePresets(presettable.ID(), presets); err != nil {
+		m.Err = err
+		return m
+	}
+
+	m.Presets = presets
+	m.SelectedPresetIndex = index
+	return m
+}
+
+func (m Model) updateModulation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	target, ok := m.ActiveController.(automation.Target)
+	params := m.modulatableParams()
+	if !ok || len(params) == 0 {
+		m.Screen = ScreenMain
+		return m, nil
+	}
+
+	if m.SelectedParamIndex >= len(params) {
+		m.SelectedParamIndex = len(params) - 1
+	}
+	param := params[m.SelectedParamIndex].Name
+	index := m.findModulator(target, param)
+
+	switch msg.String() {
+	case "esc", "q":
+		m.Screen = ScreenMain
+		return m, nil
+
+	case "up", "k":
+		if m.SelectedParamIndex > 0 {
+			m.SelectedParamIndex--
+		}
+		return m, nil
+
+	case "down", "j":
+		if m.SelectedParamIndex < len(params)-1 {
+			m.SelectedParamIndex++
+		}
+		return m, nil
+
+	case "enter":
+		// Assign or remove a modulator on the selected parameter
+		if index >= 0 {
+			m.Modulators[index].Release()
+			m.Modulators = append(m.Modulators[:index:index], m.Modulators[index+1:]...)
+			return m, nil
+		}
+		m.Modulators = append(m.Modulators, automation.NewModulator(target, param, time.Now().UnixNano()))
+		return m.startTicking()
+	}
+
+	// Remaining keys adjust the selected parameter's modulator
+	if index < 0 {
+		return m, nil
+	}
+	mod := m.Modulators[index]
+
+	switch msg.String() {
+	case "s":
+		mod.Shape = mod.Shape.Next()
+	case "r":
+		mod.Rate = math.Max(automation.MinRate, mod.Rate/1.25)
+	case "R":
+		mod.Rate = math.Min(automation.MaxRate, mod.Rate*1.25)
+	case "d":
+		mod.Depth = math.Max(0, math.Round((mod.Depth-0.05)*100)/100)
+	case "D":
+		mod.Depth = math.Min(1, math.Round((mod.Depth+0.05)*100)/100)
+	case "f":
+		mod.Phase = math.Max(0, math.Round((mod.Phase-0.05)*100)/100)
+	case "F":
+		mod.Phase = math.Min(1, math.Round((mod.Phase+0.05)*100)/100)
+	case "t":
+		mod.Retrigger()
+	}
+
+	return m, nil
+}
+
+// transitionStep is the probability change per keypress in the matrix editor
+const transitionStep = 0.05
+
+func (m Model) updateTransitions(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	editor, ok := m.ActiveController.(controllers.TransitionEditor)
+	if !ok || len(editor.Voices()) == 0 {
+		m.Screen = ScreenMain
+		return m, nil
+	}
+
+	voice := editor.Voices()[m.SelectedVoiceIndex].Name
+	matrix := editor.Matrix(voice)
+	states := matrix.States()
+	m.MatrixRow = min(m.MatrixRow, len(states)-1)
+	m.MatrixCol = min(m.MatrixCol, len(states)-1)
+	from, to := states[m.MatrixRow], states[m.MatrixCol]
+
+	key := msg.String()
+	switch key {
+	case "esc", "q":
+		m.Screen = ScreenMain
+
+	case "up", "k":
+		m.MatrixRow = max(0, m.MatrixRow-1)
+	case "down", "j":
+		m.MatrixRow = min(len(states)-1, m.MatrixRow+1)
+	case "left", "h":
+		m.MatrixCol = max(0, m.MatrixCol-1)
+	case "right", "l":
+		m.MatrixCol = min(len(states)-1, m.MatrixCol+1)
+
+	case "-":
+		editor.SetTransition(voice, from, to, matrix.Get(from, to)-transitionStep)
+	case "=", "+":
+		editor.SetTransition(voice, from, to, matrix.Get(from, to)+transitionStep)
+
+	case "a":
+		// Add the next extra state the chain doesn't have yet
+		for _, state := range editor.ExtraStates() {
+			if editor.AddState(voice, state) {
+				break
+			}
+		}
+
+	case "d":
+		// Remove the highlighted row's state (base states stay)
+		editor.RemoveState(voice, from)
+
+	case "r":
+		// Refresh from sclang
+		editor.QueryMatrix(voice)
+	}
+
+	// Voice selection
+	if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+		if index := int(key[0] - '1'); index < len(editor.Voices()) {
+			m.SelectedVoiceIndex = index
+			m.MatrixRow, m.MatrixCol = 0, 0
+			editor.QueryMatrix(editor.Voices()[index].Name)
+		}
+	}
+
+	return m, nil
+}
+
+func (m Model) updateArrangement(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.Screen = ScreenMain
+		return m, nil
+
+	case "enter":
+		// Play the arrangement from the first section
+		m = m.layerArrangement()
+		m.Conductor.Start(time.Now())
+		if err := m.Conductor.Err(); err != nil {
+			m.Err = err
+		}
+		return m.startTicking()
+
+	case "n":
+		// Skip to the next section
+		m.Conductor.Skip(time.Now())
+		if err := m.Conductor.Err(); err != nil {
+			m.Err = err
+		}
+		return m, nil
+
+	case "s":
+		// Stop the arrangement and every pattern it started
+		m.Conductor.Stop()
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m Model) updateFX(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.Screen = ScreenMain
+		return m, nil
+
+	case "ctrl+r":
+		// Read the reverb's settings back from scsynth
+		if syncer, ok := m.FX.(controllers.Syncer); ok {
+			syncer.Query()
+		}
+		return m, nil
+	}
+
+	m.FX.HandleInput(msg)
+	return m, nil
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"strings"
 
+	"forbidden_sequencer/adapter"
+	"forbidden_sequencer/automation"
+	"forbidden_sequencer/controllers"
+
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
 )
@@ -17,6 +21,16 @@ func (m Model) View() string {
 		return m.viewSettings()
 	case ScreenPatternSelect:
 		return m.viewPatternSelect()
+	case ScreenPresets:
+		return m.viewPresets()
+	case ScreenModulation:
+		return m.viewModulation()
+	case ScreenTransitions:
+		return m.viewTransitions()
+	case ScreenArrangement:
+		return m.viewArrangement()
+	case ScreenFX:
+		return m.viewFX()
 	}
 	return ""
 }
@@ -29,6 +43,12 @@ func (m Model) viewMain() string {
 	left.WriteString(TitleStyle.Render("Forbidden Sequencer"))
 	left.WriteString("\n\n")
 
+	// Connection health and server load
+	if m.SClangAdapter != nil {
+		left.WriteString(m.connectionStatus())
+		left.WriteString("\n\n")
+	}
+
 	// Active controller name with index
 	if m.ActiveController != nil {
 		controllerInfo := fmt.Sprintf("Pattern: %s (%d/%d)",
@@ -36,7 +56,26 @@ func (m Model) viewMain() string {
 			m.ActiveControllerIndex+1,
 			len(m.AvailableControllers))
 		left.WriteString(StatusStyle.Render(controllerInfo))
-		left.WriteString("\n\n")
+		left.WriteString("\n")
+
+		// Global tempo
+		if m.Clock != nil {
+			left.WriteString(StatusStyle.Render("Tempo: " + m.Clock.Describe()))
+			left.WriteString("\n")
+		}
+
+		// Global reverb
+		if summarizer, ok := m.FX.(controllers.Summarizer); ok {
+			left.WriteString(StatusStyle.Render("Reverb: " + summarizer.Summary()))
+			left.WriteString("\n")
+		}
+		left.WriteString("\n")
+
+		// Running layers
+		if layers := m.layersStatus(); layers != "" {
+			left.WriteString(StatusStyle.Render(layers))
+			left.WriteString("\n\n")
+		}
 
 		// Controller status
 		status := m.ActiveController.GetStatus()
@@ -44,6 +83,30 @@ func (m Model) viewMain() string {
 			left.WriteString(StatusStyle.Render(status))
 			left.WriteString("\n\n")
 		}
+
+		// Phrase timeline
+		if renderer, ok := m.ActiveController.(controllers.TimelineRenderer); ok {
+			left.WriteString(TimelineStyle.Render(renderer.Timeline(m.timelineWidth())))
+			left.WriteString("\n\n")
+		}
+	}
+
+	// Modulated parameters of the active controller
+	if modulation := m.modulationStatus(); modulation != "" {
+		left.WriteString(StatusStyle.Render(modulation))
+		left.WriteString("\n\n")
+	}
+
+	// Running arrangement
+	if m.Conductor != nil && m.Conductor.Running() {
+		left.WriteString(StatusStyle.Render(m.arrangementStatus()))
+		left.WriteString("\n\n")
+	}
+
+	// Running morph
+	if m.Morph != nil {
+		left.WriteString(StatusStyle.Render(m.morphStatus()))
+		left.WriteString("\n\n")
 	}
 
 	// Error display
@@ -52,6 +115,12 @@ func (m Model) viewMain() string {
 		left.WriteString("\n\n")
 	}
 
+	// OSC traffic
+	if m.ShowLog {
+		left.WriteString(m.viewLog())
+		left.WriteString("\n\n")
+	}
+
 	// Help - keybindings
 	if m.ActiveController != nil {
 		// Controller-specific keybindings
@@ -72,7 +141,37 @@ func (m Model) viewMain() string {
 			}
 
 			// Add global keybindings
+			rows = append(rows, []string{"t", "Tap tempo"})
+			rows = append(rows, []string{"-/=", "Tempo (BPM)"})
+			rows = append(rows, []string{"</>", "Nudge slower/faster"})
+			rows = append(rows, []string{"T", "Subdivision"})
 			rows = append(rows, []string{"tab", "Select pattern"})
+			rows = append(rows, []string{"ctrl+r", "Resync with sclang"})
+			rows = append(rows, []string{"ctrl+p", "Push all to sclang"})
+			rows = append(rows, []string{"P", "Presets / morph"})
+			rows = append(rows, []string{"L", "Modulation (LFOs)"})
+			if muter, ok := m.ActiveController.(controllers.VoiceMuter); ok && len(muter.Voices()) > 0 {
+				rows = append(rows, []string{"M", "Solo synth (all patterns)"})
+			}
+			if _, ok := m.ActiveController.(controllers.TransitionEditor); ok {
+				rows = append(rows, []string{"X", "Transition matrices"})
+			}
+			if m.Conductor != nil {
+				rows = append(rows, []string{"ctrl+a", "Arrangement"})
+			}
+			if m.FX != nil {
+				rows = append(rows, []string{"ctrl+f", "Effects (reverb)"})
+			}
+			rows = append(rows, []string{"ctrl+o", "Settings"})
+			rows = append(rows, []string{"ctrl+l", "OSC log"})
+			if m.ShowLog {
+				rows = append(rows, []string{"pgup/pgdn/end", "Scroll log"})
+				rows = append(rows, []string{"ctrl+g", "Filter log (address prefix)"})
+				rows = append(rows, []string{"ctrl+e", "Pause log"})
+			}
+			if m.Morph != nil {
+				rows = append(rows, []string{"ctrl+x", "Abort morph"})
+			}
 			rows = append(rows, []string{"q", "Quit"})
 
 			// Create table with blue border
@@ -96,6 +195,38 @@ func (m Model) viewMain() string {
 	return left.String()
 }
 
+// connectionStatus renders the heartbeat of sclang and scsynth and the server load
+// Connections that are down are shown in the error color
+func (m Model) connectionStatus() string {
+	health := func(name string, h adapter.Heartbeat) string {
+		text := fmt.Sprintf("%s: %s", name, h.Describe())
+		if h.Health() == adapter.HealthDown {
+			return ErrorStyle.Render(text)
+		}
+		return StatusStyle.Render(text)
+	}
+
+	parts := []string{health("sclang", m.SClangHealth)}
+	if m.ScsynthAdapter != nil {
+		parts = append(parts, health("scsynth", m.ScsynthHealth))
+		if m.ScsynthHealth.Health() != adapter.HealthDown && m.ScsynthHealth.Health() != adapter.HealthUnknown {
+			s := m.ServerStatus
+			parts = append(parts, StatusStyle.Render(fmt.Sprintf("CPU %.1f%% (peak %.1f%%), %d UGens, %d synths", s.AvgCPU, s.PeakCPU, s.UGens, s.Synths)))
+		}
+	}
+	return strings.Join(parts, HelpStyle.Render(" • "))
+}
+
+// timelineWidth returns the number of columns for the timeline panel
+func (m Model) timelineWidth() int {
+	const defaultWidth, maxWidth = 64, 96
+	if m.Width <= 0 {
+		return defaultWidth
+	}
+	// Leave room for the labels, border and padding
+	return max(16, min(maxWidth, m.Width-12))
+}
+
 func (m Model) viewSettings() string {
 	var b strings.Builder
 
@@ -116,19 +247,56 @@ func (m Model) viewSettings() string {
 	b.WriteString(fmt.Sprintf("Debug Logging: %s\n", debugStatus))
 	b.WriteString("\n")
 
-	// Display SuperCollider adapter configuration
+	// SuperCollider adapter configuration, editable
 	if m.SClangAdapter != nil {
 		b.WriteString(StatusStyle.Render("SuperCollider Configuration:"))
 		b.WriteString("\n")
-		b.WriteString(fmt.Sprintf("  Host: %s\n", m.SClangAdapter.GetHost()))
-		b.WriteString(fmt.Sprintf("  Port: %d (sclang)\n", m.SClangAdapter.GetPort()))
+
+		labels := [settingCount]string{
+			settingHost:        "Host",
+			settingSClangPort:  "Port (sclang)",
+			settingScsynthPort: "Port (scsynth)",
+		}
+		for i, label := range labels {
+			if i == settingScsynthPort && m.ScsynthAdapter == nil {
+				continue
+			}
+			prefix := "  "
+			if i == m.SelectedSettingIndex {
+				prefix = "> "
+			}
+
+			value := m.settingValue(i)
+			if m.EditingSetting && i == m.SelectedSettingIndex {
+				value = m.SettingInput + "_"
+			}
+
+			line := fmt.Sprintf("%s%-15s %s", prefix, label+":", value)
+			if i == m.SelectedSettingIndex {
+				b.WriteString(SelectedStyle.Inline(true).Render(line))
+			} else {
+				b.WriteString(line)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		b.WriteString(HelpStyle.Render(fmt.Sprintf("Local port for replies: %d", m.SClangAdapter.GetLocalPort())))
 		b.WriteString("\n")
 	}
 
 	b.WriteString("\n")
 
+	// Error display
+	if m.Err != nil {
+		b.WriteString(ErrorStyle.Render(fmt.Sprintf("Error: %v", m.Err)))
+		b.WriteString("\n\n")
+	}
+
 	// Help
-	help := "[esc] Back"
+	help := "[↑/↓] Navigate • [enter] Edit • [esc] Back"
+	if m.EditingSetting {
+		help = "[enter] Apply • [esc] Cancel"
+	}
 	b.WriteString(HelpStyle.Render(help))
 
 	return b.String()
@@ -148,13 +316,23 @@ func (m Model) viewPatternSelect() string {
 			prefix = "> "
 		}
 
-		// Show indicator if this is the currently active pattern
-		activeIndicator := ""
+		// Show indicators for the active pattern and layers
+		var tags []string
 		if i == m.ActiveControllerIndex {
-			activeIndicator = " (active)"
+			tags = append(tags, "active")
+		}
+		if m.isLayer(i) {
+			tags = append(tags, "layer")
+		}
+		if i == m.ActiveControllerIndex || m.isLayer(i) {
+			tags = append(tags, layerState(controller)...)
+		}
+		indicator := ""
+		if len(tags) > 0 {
+			indicator = " (" + strings.Join(tags, ", ") + ")"
 		}
 
-		line := fmt.Sprintf("%s%d. %s%s", prefix, i+1, controller.GetName(), activeIndicator)
+		line := fmt.Sprintf("%s%d. %s%s", prefix, i+1, controller.GetName(), indicator)
 
 		// Apply color inline using lipgloss without Render
 		if i == m.SelectedPatternIndex {
@@ -168,9 +346,386 @@ func (m Model) viewPatternSelect() string {
 	b.WriteString("\n")
 
 	// Help
-	help := "[↑/↓] Navigate • [enter] Select • [esc] Cancel"
+	help := "[↑/↓] Navigate • [enter] Select • [l] Layer • [space] Play/stop • [m] Mute • [esc] Cancel"
+	b.WriteString(HelpStyle.Render(help))
+
+	return b.String()
+}
+
+// layerState describes a running controller's transport and mute state
+func layerState(controller controllers.Controller) []string {
+	layer, ok := controller.(controllers.Layerable)
+	if !ok {
+		return nil
+	}
+	state := []string{"stopped"}
+	if layer.IsPlaying() {
+		state[0] = "playing"
+	}
+	if layer.Muted() {
+		state = append(state, "muted")
+	}
+	return state
+}
+
+// layersStatus lists the running layers, marking the focused one
+// Empty unless a pattern has been added as a layer
+func (m Model) layersStatus() string {
+	if len(m.Layers) == 0 {
+		return ""
+	}
+
+	lines := []string{"Layers:"}
+	for _, index := range m.runningControllers() {
+		controller := m.AvailableControllers[index]
+		prefix := "  "
+		if index == m.ActiveControllerIndex {
+			prefix = "> "
+		}
+		lines = append(lines, fmt.Sprintf("%s%-14s %s", prefix, controller.GetName(), strings.Join(layerState(controller), ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) viewPresets() string {
+	var b strings.Builder
+
+	// Title
+	title := "Presets"
+	if m.ActiveController != nil {
+		title = fmt.Sprintf("Presets: %s", m.ActiveController.GetName())
+	}
+	b.WriteString(TitleStyle.Render(title))
+	b.WriteString("\n\n")
+
+	if len(m.Presets) == 0 {
+		b.WriteString(HelpStyle.Render("No presets saved yet"))
+		b.WriteString("\n")
+	}
+
+	for i, preset := range m.Presets {
+		prefix := "  "
+		if i == m.SelectedPresetIndex {
+			prefix = "> "
+		}
+
+		// Mark morph source A
+		source := ""
+		if m.MorphSource != nil && m.MorphSource.Name == preset.Name {
+			source = " (A)"
+		}
+
+		line := fmt.Sprintf("%s%d. %s%s", prefix, i+1, preset.Name, source)
+		if i == m.SelectedPresetIndex {
+			b.WriteString(SelectedStyle.Inline(true).Render(line))
+		} else {
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+
+	// Name prompt while saving
+	if m.NamingPreset {
+		b.WriteString(StatusStyle.Render(fmt.Sprintf("Name: %s_", m.PresetNameInput)))
+		b.WriteString("\n\n")
+		b.WriteString(HelpStyle.Render("[enter] Save • [esc] Cancel"))
+		return b.String()
+	}
+
+	// Morph settings
+	source := "current values"
+	if m.MorphSource != nil {
+		source = m.MorphSource.Name
+	}
+	b.WriteString(StatusStyle.Render(fmt.Sprintf("Morph: from %s over %d %s", source, m.MorphLength, m.MorphUnit)))
+	b.WriteString("\n")
+	if m.Morph != nil {
+		b.WriteString(StatusStyle.Render(m.morphStatus()))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	// Error display
+	if m.Err != nil {
+		b.WriteString(ErrorStyle.Render(fmt.Sprintf("Error: %v", m.Err)))
+		b.WriteString("\n\n")
+	}
+
+	// Help
+	help := "[↑/↓] Navigate • [enter] Recall • [s] Save new • [o] Overwrite • [d] Delete • [esc] Back"
+	b.WriteString(HelpStyle.Render(help))
+	b.WriteString("\n")
+	morphHelp := "[a] Set A • [m] Morph A→selected • [[/]] Length • [u] Unit • [space] Pause • [x] Abort"
+	b.WriteString(HelpStyle.Render(morphHelp))
+
+	return b.String()
+}
+
+// morphStatus describes the running morph
+func (m Model) morphStatus() string {
+	state := "Morphing"
+	if m.Morph.Paused() {
+		state = "Morph paused"
+	}
+	status := fmt.Sprintf("%s: %3.0f%% (%.1fs left)", state, m.Morph.Progress()*100, m.Morph.Remaining().Seconds())
+	if m.MorphInBars {
+		status += " - no phrase length, counting bars at the clock tempo"
+	}
+	return status
+}
+
+// arrangementStatus shows the current section, time left and what's next
+func (m Model) arrangementStatus() string {
+	current := m.Conductor.Current()
+	status := fmt.Sprintf("Section: %s (%d/%d), %.1fs left",
+		current.Name,
+		m.Conductor.Index()+1,
+		len(m.Conductor.Arrangement().Sections),
+		m.Conductor.Remaining().Seconds())
+	if next := m.Conductor.Next(); next != nil {
+		status += "\nNext: " + next.Name
+	} else {
+		status += "\nNext: end"
+	}
+	return status
+}
+
+func (m Model) viewArrangement() string {
+	var b strings.Builder
+	arr := m.Conductor.Arrangement()
+
+	// Title
+	title := "Arrangement"
+	if arr.Name != "" {
+		title += ": " + arr.Name
+	}
+	b.WriteString(TitleStyle.Render(title))
+	b.WriteString("\n\n")
+
+	for i, section := range arr.Sections {
+		prefix := "  "
+		if i == m.Conductor.Index() {
+			prefix = "> "
+		}
+
+		line := fmt.Sprintf("%s%d. %-12s %s", prefix, i+1, section.Name, section.Describe())
+		if i == m.Conductor.Index() {
+			b.WriteString(SelectedStyle.Inline(true).Render(line))
+		} else {
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+	if arr.Loop {
+		b.WriteString("  (loops)\n")
+	}
+	b.WriteString("\n")
+
+	if m.Conductor.Running() {
+		b.WriteString(StatusStyle.Render(fmt.Sprintf("%s\nProgress: %3.0f%%", m.arrangementStatus(), m.Conductor.Progress()*100)))
+	} else {
+		b.WriteString(StatusStyle.Render("Stopped"))
+	}
+	b.WriteString("\n\n")
+
+	// Error display
+	if m.Err != nil {
+		b.WriteString(ErrorStyle.Render(fmt.Sprintf("Error: %v", m.Err)))
+		b.WriteString("\n\n")
+	}
+
+	// Help
+	help := "[enter] Play from start • [n] Next section • [s] Stop • [esc] Back"
+	b.WriteString(HelpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m Model) viewModulation() string {
+	var b strings.Builder
+
+	// Title
+	b.WriteString(TitleStyle.Render(fmt.Sprintf("Modulation: %s", m.ActiveController.GetName())))
+	b.WriteString("\n\n")
+
+	target, _ := m.ActiveController.(automation.Target)
+	for i, p := range m.modulatableParams() {
+		prefix := "  "
+		if i == m.SelectedParamIndex {
+			prefix = "> "
+		}
+
+		line := fmt.Sprintf("%s%-16s %8s", prefix, p.Name, p.FormatValue(target.Value(p.Name)))
+		if index := m.findModulator(target, p.Name); index >= 0 {
+			line += "  ~ " + m.Modulators[index].Describe()
+		}
+
+		if i == m.SelectedParamIndex {
+			b.WriteString(SelectedStyle.Inline(true).Render(line))
+		} else {
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+
+	// Help
+	help := "[↑/↓] Navigate • [enter] Assign/remove • [s] Shape • [r/R] Rate • [d/D] Depth • [f/F] Phase • [t] Retrigger • [esc] Back"
 	b.WriteString(HelpStyle.Render(help))
 
 	return b.String()
 }
 
+// modulationStatus lists the active controller's modulated parameters and live values
+func (m Model) modulationStatus() string {
+	target, ok := m.ActiveController.(automation.Target)
+	if !ok {
+		return ""
+	}
+
+	var lines []string
+	for _, mod := range m.Modulators {
+		if mod.Target() != target {
+			continue
+		}
+		value := fmt.Sprintf("%.2f", mod.Value())
+		if p := target.Param(mod.Param()); p != nil {
+			value = p.FormatValue(mod.Value())
+		}
+		lines = append(lines, fmt.Sprintf("  %s ~ %s → %s", mod.Param(), mod.Describe(), value))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "Modulation:\n" + strings.Join(lines, "\n")
+}
+
+// Transition preview settings (fixed seed so the preview only changes with the matrix)
+const (
+	previewSeed  = 42
+	previewSteps = 48
+)
+
+func (m Model) viewTransitions() string {
+	var b strings.Builder
+
+	editor, ok := m.ActiveController.(controllers.TransitionEditor)
+	if !ok || len(editor.Voices()) == 0 {
+		return ""
+	}
+	voice := editor.Voices()[m.SelectedVoiceIndex]
+
+	// Title
+	b.WriteString(TitleStyle.Render(fmt.Sprintf("Transitions: %s", m.ActiveController.GetName())))
+	b.WriteString("\n\n")
+
+	// Voice tabs
+	var tabs []string
+	for i, v := range editor.Voices() {
+		tab := fmt.Sprintf("%d. %s", i+1, v.Label)
+		if i == m.SelectedVoiceIndex {
+			tab = SelectedStyle.Render("[" + tab + "]")
+		}
+		tabs = append(tabs, tab)
+	}
+	b.WriteString(strings.Join(tabs, "  "))
+	b.WriteString("\n\n")
+
+	// Matrix: rows are "from" states, columns "to" states
+	matrix := editor.Matrix(voice.Name)
+	states := matrix.States()
+	const cell = 12
+
+	header := fmt.Sprintf("%-*s", cell, "from \\ to")
+	for _, to := range states {
+		header += fmt.Sprintf("%*s", cell, to)
+	}
+	b.WriteString(HelpStyle.Render(header))
+	b.WriteString("\n")
+
+	for row, from := range states {
+		line := fmt.Sprintf("%-*s", cell, from)
+		if row == m.MatrixRow {
+			line = SelectedStyle.Render(line)
+		}
+		for col, to := range states {
+			value := fmt.Sprintf("%*s", cell, controllers.FormatPercent(matrix.Get(from, to)))
+			if row == m.MatrixRow && col == m.MatrixCol {
+				value = SelectedStyle.Inline(true).Reverse(true).Render(value)
+			}
+			line += value
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(StatusStyle.Render("Each row is normalised to 100% after an edit"))
+	b.WriteString("\n\n")
+
+	// Long-run behaviour and a seeded preview of the chain
+	chain := matrix.Chain(previewSeed)
+	stationary := chain.Stationary()
+	var shares []string
+	for _, state := range states {
+		shares = append(shares, fmt.Sprintf("%s %s", state, controllers.FormatPercent(stationary[state])))
+	}
+	b.WriteString(StatusStyle.Render("Long run: " + strings.Join(shares, ", ")))
+	b.WriteString("\n")
+
+	off := editor.BaseStates(voice.Name)[1]
+	chain.SetState(states[0])
+	var preview strings.Builder
+	for _, state := range chain.Simulate(previewSteps) {
+		switch {
+		case state == off:
+			preview.WriteString("·")
+		case state == states[0]:
+			preview.WriteString("x")
+		default:
+			preview.WriteString(state[:1])
+		}
+	}
+	b.WriteString(StatusStyle.Render("Preview:  " + preview.String()))
+	b.WriteString("\n\n")
+
+	// Help
+	help := "[1-9] Voice • [←/→/↑/↓] Cell • [-/=] Probability • [a] Add accent/ghost • [d] Remove row state • [r] Refresh • [esc] Back"
+	b.WriteString(HelpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m Model) viewFX() string {
+	var b strings.Builder
+
+	// Title
+	b.WriteString(TitleStyle.Render(m.FX.GetName()))
+	b.WriteString("\n\n")
+
+	b.WriteString(StatusStyle.Render(fmt.Sprintf("Node %d in the effects group, reading audio bus %d, fed by every synth's reverb send", adapter.ReverbNodeID, adapter.ReverbBus)))
+	b.WriteString("\n\n")
+	b.WriteString(StatusStyle.Render(strings.ReplaceAll(m.FX.GetStatus(), ", ", "\n")))
+	b.WriteString("\n\n")
+
+	// Error display
+	if m.Err != nil {
+		b.WriteString(ErrorStyle.Render(fmt.Sprintf("Error: %v", m.Err)))
+		b.WriteString("\n\n")
+	}
+
+	// Help
+	var keys []string
+	for _, line := range strings.Split(m.FX.GetKeybindings(), "\n") {
+		if key, desc, ok := strings.Cut(line, ": "); ok {
+			keys = append(keys, fmt.Sprintf("[%s] %s", key, desc))
+		}
+	}
+	keys = append(keys, "[ctrl+r] Read from scsynth", "[esc] Back")
+	b.WriteString(HelpStyle.Render(strings.Join(keys, " • ")))
+
+	return b.String()
+}
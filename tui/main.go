@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 
 	"forbidden_sequencer/adapter"
+	"forbidden_sequencer/arrangement"
+	"forbidden_sequencer/clock"
 	"forbidden_sequencer/controllers"
 	tui "forbidden_sequencer/internal/ui"
 
@@ -13,6 +16,38 @@ import (
 )
 
 var debug = flag.Bool("debug", false, "Enable debug logging")
+var patternsDir = flag.String("patterns", "", "Directory scanned for pattern manifests (.json/.yaml), defaults to ../Supercollider/patterns next to the binary, then patterns/ in the config directory")
+var arrangementPath = flag.String("arrangement", "", "Arrangement file (.json/.yaml), defaults to arrangement.yaml in the config directory")
+var host = flag.String("host", "", "SuperCollider host (overrides $FORBIDDEN_SC_HOST and the saved setting)")
+var port = flag.Int("port", 0, "sclang port (overrides $FORBIDDEN_SC_PORT and the saved setting)")
+
+// oscTarget returns the host and sclang port to use: the saved settings,
+// overridden by the environment, overridden by flags
+// Overrides apply to this run only and are not saved
+func oscTarget(settings *tui.Settings) (string, int, error) {
+	targetHost, targetPort, _ := settings.Target()
+
+	if env := os.Getenv("FORBIDDEN_SC_HOST"); env != "" {
+		targetHost = env
+	}
+	var err error
+	if env := os.Getenv("FORBIDDEN_SC_PORT"); env != "" {
+		if p, parseErr := tui.ParsePort(env); parseErr != nil {
+			err = fmt.Errorf("ignoring FORBIDDEN_SC_PORT: %w", parseErr)
+		} else {
+			targetPort = p
+		}
+	}
+
+	if *host != "" {
+		targetHost = *host
+	}
+	if *port != 0 {
+		targetPort = *port
+	}
+
+	return targetHost, targetPort, err
+}
 
 func initialModel() tui.Model {
 	// Load settings
@@ -24,21 +59,43 @@ func initialModel() tui.Model {
 		}
 	}
 
+	// Startup problems are collected and shown together
+	var errs []error
+
 	// Initialize sclang OSC adapter (for pattern control)
-	sclangAdapter, err := adapter.SetupSClangAdapter()
+	targetHost, targetPort, targetErr := oscTarget(settings)
+	errs = append(errs, targetErr)
+	sclangAdapter, err := adapter.SetupSClangAdapter(targetHost, targetPort)
 	if err != nil {
 		return tui.Model{
 			Settings: settings,
 			Screen:   tui.ScreenMain,
-			Err:      err,
+			Err:      errors.Join(append(errs, err)...),
 		}
 	}
 
 	m := tui.Model{
-		Settings:      settings,
-		Screen:        tui.ScreenMain,
-		SClangAdapter: sclangAdapter,
-		Debug:         *debug,
+		Settings:       settings,
+		Screen:         tui.ScreenMain,
+		SClangAdapter:  sclangAdapter,
+		SClangMessages: sclangAdapter.Subscribe(""),
+		Debug:          *debug,
+		ShowLog:        *debug, // debugging starts with the OSC log open
+		Clock:          clock.New(settings.BPM, settings.Subdivision),
+		MorphLength:    4, // phrases
+	}
+	sclangAdapter.Listen()
+
+	// Initialize scsynth OSC adapter (for the global effects), on the same host
+	_, _, serverPort := settings.Target()
+	scsynthAdapter, err := adapter.SetupScsynthAdapter(targetHost, serverPort)
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		m.ScsynthAdapter = scsynthAdapter
+		m.ScsynthMessages = scsynthAdapter.Subscribe("")
+		m.FX = controllers.NewFXController(scsynthAdapter)
+		scsynthAdapter.Listen()
 	}
 
 	// Create all available controllers
@@ -46,8 +103,20 @@ func initialModel() tui.Model {
 		controllers.NewCurveTimeController(sclangAdapter),
 		controllers.NewMarkovTrigController(sclangAdapter),
 		controllers.NewMarkovChordController(sclangAdapter),
+		controllers.NewEuclidController(sclangAdapter),
 	}
 
+	// Add controllers described by pattern manifests
+	dir := *patternsDir
+	if dir == "" {
+		dir = tui.DefaultPatternsDir()
+	}
+	manifestControllers, err := controllers.LoadManifests(dir, sclangAdapter)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to load pattern manifests: %w", err))
+	}
+	m.AvailableControllers = appendNewNamespaces(m.AvailableControllers, manifestControllers)
+
 	// Set initial controller from settings (with bounds checking)
 	m.ActiveControllerIndex = settings.SelectedControllerIndex
 	if m.ActiveControllerIndex < 0 || m.ActiveControllerIndex >= len(m.AvailableControllers) {
@@ -56,9 +125,61 @@ func initialModel() tui.Model {
 	}
 	m.ActiveController = m.AvailableControllers[m.ActiveControllerIndex]
 
+	// Load the arrangement for song mode, if there is one
+	conductor, err := loadConductor(m)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	m.Conductor = conductor
+
+	// errors.Join skips nils, and returns nil if there were no problems
+	m.Err = errors.Join(errs...)
+
 	return m
 }
 
+// loadConductor reads the arrangement and prepares a conductor for it
+// Returns nil without an error if there is no arrangement file
+func loadConductor(m tui.Model) (*arrangement.Conductor, error) {
+	path := *arrangementPath
+	if path == "" {
+		var err error
+		if path, err = tui.DefaultArrangementPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	arr, err := arrangement.Load(path)
+	if err != nil || arr == nil {
+		return nil, err
+	}
+
+	// Patterns without a phrase length count one bar at the global tempo
+	return arrangement.NewConductor(arr, m.AvailableControllers, tui.LookupPreset, m.Clock.BarDuration)
+}
+
+// appendNewNamespaces adds controllers whose OSC namespace isn't already taken
+// Built-in controllers win over manifests for the same pattern
+func appendNewNamespaces(existing, added []controllers.Controller) []controllers.Controller {
+	type namespaced interface{ Namespace() string }
+
+	taken := make(map[string]bool)
+	for _, c := range existing {
+		if n, ok := c.(namespaced); ok {
+			taken[n.Namespace()] = true
+		}
+	}
+
+	for _, c := range added {
+		if n, ok := c.(namespaced); ok && taken[n.Namespace()] {
+			continue
+		}
+		existing = append(existing, c)
+	}
+
+	return existing
+}
+
 func main() {
 	flag.Parse()
 
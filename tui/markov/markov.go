@@ -0,0 +1,196 @@
+package markov
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Chain is a seeded first-order Markov chain between named states
+// It mirrors ~newMarkovChain in Supercollider/lib/markov.scd so the TUI can
+// preview and analyse the chains the patterns play. Runs are deterministic:
+// the same seed and transitions always produce the same sequence
+type Chain struct {
+	seed        int64
+	rng         *rand.Rand
+	states      []string                      // in order of first use, for deterministic choices
+	transitions map[string]map[string]float64 // from -> to -> probability
+	current     string                        // "" when there is no current state
+}
+
+// New creates an empty chain with the given seed
+func New(seed int64) *Chain {
+	return &Chain{
+		seed:        seed,
+		rng:         rand.New(rand.NewSource(seed)),
+		transitions: make(map[string]map[string]float64),
+	}
+}
+
+// SetTransition sets the probability of moving from one state to another
+// Rows are not normalised automatically (see Normalize)
+func (c *Chain) SetTransition(from, to string, p float64) {
+	c.addState(from)
+	c.addState(to)
+	c.transitions[from][to] = p
+}
+
+// Transition returns the probability of moving from one state to another
+func (c *Chain) Transition(from, to string) float64 {
+	return c.transitions[from][to]
+}
+
+// Normalize scales the transitions from a state so they sum to 1
+// Rows summing to 0 are left alone, as in normalizeTransitions
+func (c *Chain) Normalize(from string) {
+	row := c.transitions[from]
+	sum := 0.0
+	for _, p := range row {
+		sum += p
+	}
+	if sum <= 0 {
+		return
+	}
+	for to, p := range row {
+		row[to] = p / sum
+	}
+}
+
+// States returns every state in order of first use
+func (c *Chain) States() []string {
+	return c.states
+}
+
+// State returns the current state ("" before the first Next)
+func (c *Chain) State() string {
+	return c.current
+}
+
+// SetState sets the current state; returns false if the state is unknown
+func (c *Chain) SetState(state string) bool {
+	if _, ok := c.transitions[state]; !ok {
+		return false
+	}
+	c.current = state
+	return true
+}
+
+// Reset clears the current state and rewinds the random sequence,
+// so a reset chain replays the same states
+func (c *Chain) Reset() {
+	c.current = ""
+	c.rng = rand.New(rand.NewSource(c.seed))
+}
+
+// Next advances to and returns the next state
+// Without a current state a random starting state is picked; a state with no
+// outgoing transitions stays where it is. Returns false for an empty chain
+func (c *Chain) Next() (string, bool) {
+	if len(c.states) == 0 {
+		return "", false
+	}
+
+	if c.current == "" {
+		c.current = c.states[c.rng.Intn(len(c.states))]
+		return c.current, true
+	}
+
+	// Weighted choice over the row, like wchoose with normalizeSum
+	row := c.transitions[c.current]
+	sum := 0.0
+	for _, to := range c.states {
+		sum += row[to]
+	}
+	if sum <= 0 {
+		return c.current, true
+	}
+
+	r := c.rng.Float64() * sum
+	for _, to := range c.states {
+		p := row[to]
+		if p <= 0 {
+			continue
+		}
+		if r < p {
+			c.current = to
+			return c.current, true
+		}
+		r -= p
+	}
+
+	// Rounding left r just past the last weight
+	for i := len(c.states) - 1; i >= 0; i-- {
+		if row[c.states[i]] > 0 {
+			c.current = c.states[i]
+			break
+		}
+	}
+	return c.current, true
+}
+
+// Simulate advances the chain steps times and returns the states visited
+func (c *Chain) Simulate(steps int) []string {
+	visited := make([]string, 0, steps)
+	for range steps {
+		state, ok := c.Next()
+		if !ok {
+			break
+		}
+		visited = append(visited, state)
+	}
+	return visited
+}
+
+// Stationary returns the long-run share of time spent in each state,
+// found by power iteration from an even start (rows are normalised on the fly)
+// Each step keeps half the previous distribution so periodic chains converge too
+func (c *Chain) Stationary() map[string]float64 {
+	const iterations, tolerance = 1000, 1e-9
+
+	dist := make(map[string]float64, len(c.states))
+	for _, s := range c.states {
+		dist[s] = 1 / float64(len(c.states))
+	}
+
+	for range iterations {
+		next := make(map[string]float64, len(c.states))
+		for _, s := range c.states {
+			next[s] = dist[s] / 2
+		}
+		for _, from := range c.states {
+			row := c.transitions[from]
+			sum := 0.0
+			for _, p := range row {
+				sum += p
+			}
+			if sum <= 0 {
+				// No way out - the state keeps its share
+				next[from] += dist[from] / 2
+				continue
+			}
+			for to, p := range row {
+				next[to] += dist[from] * p / sum / 2
+			}
+		}
+
+		delta := 0.0
+		for _, s := range c.states {
+			delta += math.Abs(next[s] - dist[s])
+		}
+		dist = next
+		if delta < tolerance {
+			break
+		}
+	}
+
+	return dist
+}
+
+// addState registers a state the first time it is used, as a source or a
+// destination; markov.scd's setTransition registers both the same way
+func (c *Chain) addState(state string) {
+	if _, ok := c.transitions[state]; ok {
+		return
+	}
+	c.states = append(c.states, state)
+	c.transitions[state] = make(map[string]float64)
+}
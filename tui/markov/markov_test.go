@@ -0,0 +1,176 @@
+package markov
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// testChain returns a three-state chain with uneven, unnormalised rows
+func testChain(seed int64) *Chain {
+	c := New(seed)
+	c.SetTransition("kick", "kick", 1)
+	c.SetTransition("kick", "snare", 2)
+	c.SetTransition("kick", "hat", 1)
+	c.SetTransition("snare", "kick", 3)
+	c.SetTransition("snare", "hat", 1)
+	c.SetTransition("hat", "kick", 1)
+	c.SetTransition("hat", "snare", 1)
+	c.SetTransition("hat", "hat", 2)
+	return c
+}
+
+func TestSameSeedSameSequence(t *testing.T) {
+	a := testChain(42).Simulate(200)
+	b := testChain(42).Simulate(200)
+	if !slices.Equal(a, b) {
+		t.Fatal("chains with the same seed produced different sequences")
+	}
+
+	if other := testChain(7).Simulate(200); slices.Equal(a, other) {
+		t.Error("chains with different seeds produced the same sequence")
+	}
+}
+
+func TestTransitionFrequenciesMatchRows(t *testing.T) {
+	const steps, tolerance = 200000, 0.01
+
+	c := testChain(1)
+	for _, s := range c.States() {
+		c.Normalize(s)
+	}
+
+	counts := make(map[string]map[string]int)
+	totals := make(map[string]int)
+	visited := c.Simulate(steps)
+	for i := 1; i < len(visited); i++ {
+		from, to := visited[i-1], visited[i]
+		if counts[from] == nil {
+			counts[from] = make(map[string]int)
+		}
+		counts[from][to]++
+		totals[from]++
+	}
+
+	for _, from := range c.States() {
+		for _, to := range c.States() {
+			got := float64(counts[from][to]) / float64(totals[from])
+			want := c.Transition(from, to)
+			if math.Abs(got-want) > tolerance {
+				t.Errorf("%s -> %s: observed %.4f, want %.4f", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		row  map[string]float64
+		want map[string]float64
+	}{
+		{"uneven", map[string]float64{"a": 1, "b": 3}, map[string]float64{"a": 0.25, "b": 0.75}},
+		{"already normal", map[string]float64{"a": 0.5, "b": 0.5}, map[string]float64{"a": 0.5, "b": 0.5}},
+		{"single", map[string]float64{"a": 4}, map[string]float64{"a": 1}},
+		{"zero row left alone", map[string]float64{"a": 0, "b": 0}, map[string]float64{"a": 0, "b": 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(0)
+			for to, p := range tt.row {
+				c.SetTransition("from", to, p)
+			}
+			c.Normalize("from")
+
+			sum := 0.0
+			for to, want := range tt.want {
+				got := c.Transition("from", to)
+				if math.Abs(got-want) > 1e-12 {
+					t.Errorf("from -> %s = %g, want %g", to, got, want)
+				}
+				sum += got
+			}
+			if tt.name != "zero row left alone" && math.Abs(sum-1) > 1e-12 {
+				t.Errorf("row sums to %g, want 1", sum)
+			}
+		})
+	}
+}
+
+func TestResetReplaysSequence(t *testing.T) {
+	c := testChain(99)
+	first := c.Simulate(100)
+	c.Reset()
+	if c.State() != "" {
+		t.Errorf("State() after Reset = %q, want none", c.State())
+	}
+	if second := c.Simulate(100); !slices.Equal(first, second) {
+		t.Error("reset chain didn't replay the same sequence")
+	}
+}
+
+func TestSetState(t *testing.T) {
+	c := testChain(0)
+	if c.SetState("clap") {
+		t.Error("SetState accepted an unknown state")
+	}
+	if c.State() != "" {
+		t.Errorf("State() after a rejected SetState = %q, want none", c.State())
+	}
+	if !c.SetState("snare") || c.State() != "snare" {
+		t.Errorf("SetState(snare) didn't take, State() = %q", c.State())
+	}
+}
+
+func TestNextOnEmptyAndAbsorbingChains(t *testing.T) {
+	if _, ok := New(0).Next(); ok {
+		t.Error("Next on an empty chain reported a state")
+	}
+
+	c := New(0)
+	c.SetTransition("a", "b", 1)
+	c.SetState("b")
+	for range 5 {
+		if s, _ := c.Next(); s != "b" {
+			t.Fatalf("a state with no way out moved to %q", s)
+		}
+	}
+}
+
+func TestStationaryMatchesLongRun(t *testing.T) {
+	const steps, tolerance = 200000, 0.01
+
+	c := testChain(5)
+	stationary := c.Stationary()
+
+	sum := 0.0
+	for _, p := range stationary {
+		sum += p
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		t.Errorf("stationary distribution sums to %g, want 1", sum)
+	}
+
+	counts := make(map[string]int)
+	for _, s := range c.Simulate(steps) {
+		counts[s]++
+	}
+	for _, s := range c.States() {
+		got := float64(counts[s]) / steps
+		if math.Abs(got-stationary[s]) > tolerance {
+			t.Errorf("%s: long-run share %.4f, stationary %.4f", s, got, stationary[s])
+		}
+	}
+}
+
+func TestStationaryPeriodicChain(t *testing.T) {
+	c := New(0)
+	c.SetTransition("a", "b", 1)
+	c.SetTransition("b", "a", 1)
+	for s, p := range c.Stationary() {
+		if math.Abs(p-0.5) > 1e-6 {
+			t.Errorf("%s: stationary %g, want 0.5", s, p)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package rhythm
+
+// Bjorklund spreads pulses as evenly as possible over steps (a Euclidean rhythm)
+// e.g. Bjorklund(3, 8) is x..x..x. - the same algorithm as ~euclid.bjorklund
+// in euclid.scd, so the TUI grid matches what sclang plays
+func Bjorklund(pulses, steps int) []bool {
+	if steps <= 0 {
+		return nil
+	}
+	pulses = max(0, min(steps, pulses))
+
+	// Start with one group per step, then repeatedly append the remainder
+	// groups onto the leading groups until at most one remainder is left
+	a := make([][]bool, pulses)
+	for i := range a {
+		a[i] = []bool{true}
+	}
+	b := make([][]bool, steps-pulses)
+	for i := range b {
+		b[i] = []bool{false}
+	}
+
+	for len(b) > 1 && len(a) > 0 {
+		n := min(len(a), len(b))
+		merged := make([][]bool, n)
+		for i := range merged {
+			merged[i] = append(append([]bool{}, a[i]...), b[i]...)
+		}
+
+		rest := b[n:]
+		if len(a) > len(b) {
+			rest = a[n:]
+		}
+		a, b = merged, rest
+	}
+
+	pattern := make([]bool, 0, steps)
+	for _, group := range append(a, b...) {
+		pattern = append(pattern, group...)
+	}
+	return pattern
+}
+
+// Rotate shifts a pattern right by n steps (negative n shifts left)
+func Rotate(pattern []bool, n int) []bool {
+	size := len(pattern)
+	if size == 0 {
+		return pattern
+	}
+	rotated := make([]bool, size)
+	for i, on := range pattern {
+		rotated[(((i+n)%size)+size)%size] = on
+	}
+	return rotated
+}
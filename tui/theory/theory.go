@@ -0,0 +1,116 @@
+package theory
+
+import "fmt"
+
+// Scale is a set of semitone offsets from the root within one octave
+type Scale struct {
+	Name      string
+	Intervals []int
+	Modes     []string // names of the modes, by starting degree (optional)
+}
+
+// Scales are the selectable scales
+var Scales = []Scale{
+	{Name: "major", Intervals: []int{0, 2, 4, 5, 7, 9, 11},
+		Modes: []string{"ionian", "dorian", "phrygian", "lydian", "mixolydian", "aeolian", "locrian"}},
+	{Name: "harmonic minor", Intervals: []int{0, 2, 3, 5, 7, 8, 11},
+		Modes: []string{"harmonic minor", "locrian #6", "ionian #5", "dorian #4", "phrygian dominant", "lydian #2", "altered bb7"}},
+	{Name: "melodic minor", Intervals: []int{0, 2, 3, 5, 7, 9, 11},
+		Modes: []string{"melodic minor", "dorian b2", "lydian augmented", "lydian dominant", "mixolydian b6", "locrian #2", "altered"}},
+	{Name: "major pentatonic", Intervals: []int{0, 2, 4, 7, 9}},
+	{Name: "minor pentatonic", Intervals: []int{0, 3, 5, 7, 10}},
+	{Name: "whole tone", Intervals: []int{0, 2, 4, 6, 8, 10}},
+}
+
+// Voicing is a set of scale degrees stacked into a chord (0 = root)
+// Degrees past the end of the scale continue into the next octave
+type Voicing struct {
+	Name    string
+	Degrees []int
+}
+
+// Voicings are the selectable chord-degree sets
+var Voicings = []Voicing{
+	{Name: "triad", Degrees: []int{0, 2, 4}},
+	{Name: "seventh", Degrees: []int{0, 2, 4, 6}},
+	{Name: "ninth", Degrees: []int{0, 2, 4, 6, 8}},
+	{Name: "sus2", Degrees: []int{0, 1, 4}},
+	{Name: "sus4", Degrees: []int{0, 3, 4}},
+	{Name: "quartal", Degrees: []int{0, 3, 6, 9}},
+	{Name: "open", Degrees: []int{0, 4, 9, 13}},
+}
+
+// noteNames are the pitch classes, spelled with sharps
+var noteNames = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// ScaleIndex returns the index of a scale by name, or -1
+func ScaleIndex(name string) int {
+	for i, s := range Scales {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// VoicingIndex returns the index of a voicing by name, or -1
+func VoicingIndex(name string) int {
+	for i, v := range Voicings {
+		if v.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Mode returns the scale rotated to start on the given degree
+// e.g. mode 1 of major is dorian: 0 2 3 5 7 9 10
+func (s Scale) Mode(degree int) Scale {
+	n := len(s.Intervals)
+	degree = ((degree % n) + n) % n
+
+	intervals := make([]int, n)
+	for i := range intervals {
+		j := degree + i
+		intervals[i] = s.Intervals[j%n] + 12*(j/n) - s.Intervals[degree]
+	}
+
+	return Scale{Name: s.ModeName(degree), Intervals: intervals}
+}
+
+// ModeName returns the name of the mode starting on a degree
+func (s Scale) ModeName(degree int) string {
+	if degree >= 0 && degree < len(s.Modes) {
+		return s.Modes[degree]
+	}
+	if degree == 0 {
+		return s.Name
+	}
+	return fmt.Sprintf("%s mode %d", s.Name, degree+1)
+}
+
+// Note returns the MIDI note of a scale degree above root
+func (s Scale) Note(root, degree int) int {
+	n := len(s.Intervals)
+	octave := degree / n
+	if degree < 0 && degree%n != 0 {
+		octave--
+	}
+	return root + s.Intervals[degree-octave*n] + 12*octave
+}
+
+// Chord returns the MIDI notes of a set of degrees above root
+func (s Scale) Chord(root int, degrees []int) []int {
+	notes := make([]int, len(degrees))
+	for i, d := range degrees {
+		notes[i] = s.Note(root, d)
+	}
+	return notes
+}
+
+// NoteName returns the name of a MIDI note, with middle C (60) as C4
+func NoteName(midi int) string {
+	pitch := ((midi % 12) + 12) % 12
+	octave := (midi-pitch)/12 - 1
+	return fmt.Sprintf("%s%d", noteNames[pitch], octave)
+}
@@ -0,0 +1,77 @@
+package theory
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNoteName(t *testing.T) {
+	tests := []struct {
+		midi int
+		want string
+	}{
+		{60, "C4"},
+		{61, "C#4"},
+		{69, "A4"},
+		{59, "B3"},
+		{0, "C-1"},
+		{127, "G9"},
+		{-1, "B-2"},
+	}
+
+	for _, tt := range tests {
+		if got := NoteName(tt.midi); got != tt.want {
+			t.Errorf("NoteName(%d) = %q, want %q", tt.midi, got, tt.want)
+		}
+	}
+}
+
+func TestChord(t *testing.T) {
+	major := Scales[ScaleIndex("major")]
+	degrees := func(voicing string) []int { return Voicings[VoicingIndex(voicing)].Degrees }
+
+	tests := []struct {
+		name    string
+		scale   Scale
+		root    int
+		degrees []int
+		want    []int
+	}{
+		{"major triad", major, 60, degrees("triad"), []int{60, 64, 67}},
+		{"major seventh", major, 60, degrees("seventh"), []int{60, 64, 67, 71}},
+		{"ninth wraps into the next octave", major, 60, degrees("ninth"), []int{60, 64, 67, 71, 74}},
+		{"quartal", major, 60, degrees("quartal"), []int{60, 65, 71, 76}},
+		{"dorian triad is minor", major.Mode(1), 62, degrees("triad"), []int{62, 65, 69}},
+		{"pentatonic triad", Scales[ScaleIndex("minor pentatonic")], 57, degrees("triad"), []int{57, 62, 67}},
+		{"degrees below the root", major, 60, []int{-1, -7, -8}, []int{59, 48, 47}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scale.Chord(tt.root, tt.degrees); !slices.Equal(got, tt.want) {
+				t.Errorf("Chord(%d, %v) = %v, want %v", tt.root, tt.degrees, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMode(t *testing.T) {
+	major := Scales[ScaleIndex("major")]
+
+	tests := []struct {
+		degree    int
+		name      string
+		intervals []int
+	}{
+		{0, "ionian", []int{0, 2, 4, 5, 7, 9, 11}},
+		{1, "dorian", []int{0, 2, 3, 5, 7, 9, 10}},
+		{-1, "locrian", []int{0, 1, 3, 5, 6, 8, 10}},
+	}
+
+	for _, tt := range tests {
+		mode := major.Mode(tt.degree)
+		if mode.Name != tt.name || !slices.Equal(mode.Intervals, tt.intervals) {
+			t.Errorf("Mode(%d) = %s %v, want %s %v", tt.degree, mode.Name, mode.Intervals, tt.name, tt.intervals)
+		}
+	}
+}